@@ -0,0 +1,171 @@
+// Command healthcheck runs the source's extractor against a sample of live, or cached pages and fails when the fill
+// rate of the expected fields drops compared with the stored baseline.
+package main
+
+import (
+	"bufio"
+	"cigarsdb/extract"
+	_ "cigarsdb/extract/cigarcentury"
+	_ "cigarsdb/extract/cigargeeks"
+	_ "cigarsdb/extract/cigarworld"
+	"cigarsdb/extract/declarative"
+	_ "cigarsdb/extract/noblego"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	iofs "io/fs"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+func main() {
+	var (
+		s              string
+		urlsPath       string
+		sample         int
+		page, limit    uint
+		baselinePath   string
+		updateBaseline bool
+		tolerance      float64
+		cacheDir       string
+		cacheOnly      bool
+		userAgent      string
+		specsDir       string
+		workers        int
+	)
+	flag.StringVar(&s, "i", "", "source")
+	flag.StringVar(&urlsPath, "urls", "",
+		"path to the file with the detail pages' URLs to check, one per line, the list page is checked if empty")
+	flag.IntVar(&sample, "sample", 20, "max number of the detail pages to check, all if zero")
+	flag.UintVar(&page, "page", 1, "number of the list page to check if the URLs are not set")
+	flag.UintVar(&limit, "limit", 100, "fetch limit of the list page")
+	flag.StringVar(&baselinePath, "baseline", "",
+		"path to the baseline of the fill rates, defaults to .{{source}}.health in the working directory")
+	flag.BoolVar(&updateBaseline, "update-baseline", false,
+		"store the fill rates as the baseline if the check passed and no page failed")
+	flag.Float64Var(&tolerance, "tolerance", 0.1, "allowed drop of the fill rate compared with the baseline")
+	flag.StringVar(&cacheDir, "cache-dir", "", "directory with the cached responses, disabled if empty")
+	flag.BoolVar(&cacheOnly, "cache-only", false, "serve the responses from the cache only")
+	flag.StringVar(&userAgent, "user-agent", "cigarsdb-healthcheck (+https://github.com/kislerdm/cigarsdb)",
+		"user agent to identify the requests")
+	flag.StringVar(&specsDir, "specs", "", "directory with the declarative extractor specs, disabled if empty")
+	flag.IntVar(&workers, "workers", 2, "max detail pages fetched concurrently")
+	flag.Parse()
+
+	var logs = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+
+	if specsDir != "" {
+		if err := declarative.RegisterDir(specsDir, logs); err != nil {
+			logs.Error("could not register the extractor specs", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+
+	source, ok := extract.Lookup(s)
+	if !ok {
+		logs.Error("unknown source", slog.String("source", s))
+		os.Exit(1)
+	}
+	if len(source.Expected) == 0 {
+		logs.Warn("the source declares no expected fields, only the baseline is checked", slog.String("source", s))
+	}
+
+	var (
+		httpClient extract.HTTPClient = extract.NewRobotsClient(
+			extract.NewRateLimitedClient(http.DefaultClient, extract.RatePolicy{RequestsPerSecond: 1, Burst: 2}),
+			userAgent)
+		err error
+	)
+	if cacheDir != "" {
+		if httpClient, err = extract.NewCacheClient(httpClient, cacheDir, 0, cacheOnly); err != nil {
+			logs.Error("could not initialise the cache", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+
+	baselinePath = cmp.Or(baselinePath, "."+s+".health")
+	baseline, err := extract.LoadBaseline(baselinePath)
+	if err != nil && !errors.Is(err, iofs.ErrNotExist) {
+		logs.Error("could not read the baseline", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	check := extract.HealthCheck{
+		Source:    source,
+		Reader:    source.New(extract.Config{HTTPClient: httpClient, Logs: logs, Workers: workers}),
+		Baseline:  baseline,
+		Tolerance: tolerance,
+		Workers:   workers,
+	}
+
+	var report extract.HealthReport
+	switch urlsPath {
+	case "":
+		report = check.CheckList(ctx, limit, page)
+	default:
+		urls, err := readURLs(urlsPath)
+		if err != nil {
+			logs.Error("could not read the URLs", slog.Any("error", err))
+			os.Exit(1)
+		}
+		if sample > 0 && len(urls) > sample {
+			urls = urls[:sample]
+		}
+		report = check.CheckDetails(ctx, urls)
+	}
+
+	var attrs = []any{slog.String("source", s), slog.String("page type", string(report.PageType)),
+		slog.Int("pages", report.Pages), slog.Int("failed", report.Failed)}
+	for _, field := range slices.Sorted(maps.Keys(report.FillRates)) {
+		attrs = append(attrs, slog.Float64(field, report.FillRates[field]))
+	}
+	logs.Info("fill rates", attrs...)
+
+	if !report.Healthy() {
+		for _, r := range report.Regressions {
+			logs.Error("coverage dropped", slog.String("source", s), slog.String("field", r.Field),
+				slog.Float64("fill rate", r.Actual), slog.Float64("baseline", r.Baseline))
+		}
+		if report.Failed*2 > report.Pages {
+			logs.Error("most pages failed", slog.String("source", s), slog.Int("pages", report.Pages),
+				slog.Int("failed", report.Failed))
+		}
+		os.Exit(1)
+	}
+
+	if updateBaseline {
+		if err = extract.SaveBaseline(baselinePath, report); err != nil {
+			logs.Error("could not save the baseline", slog.Any("error", err), slog.String("path", baselinePath))
+			os.Exit(1)
+		}
+	}
+}
+
+func readURLs(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var o []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if u := strings.TrimSpace(sc.Text()); u != "" && !strings.HasPrefix(u, "#") {
+			o = append(o, u)
+		}
+	}
+	return o, sc.Err()
+}
@@ -0,0 +1,221 @@
+package extract
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrCacheMiss defines the error returned in the cache-only mode when the response was not cached before.
+var ErrCacheMiss = errors.New("response is not cached")
+
+// CacheClient wraps the HTTPClient to store the raw responses of the GET requests on disk.
+// The entries are keyed by the request URL, the bodies are content-addressed, i.e., stored by their SHA256 checksum.
+// Only the responses with the status 200 are stored.
+// The stale entries are revalidated using the conditional requests, the stored body is served if the page did not change.
+type CacheClient struct {
+	HTTPClient HTTPClient
+	// Dir the directory to store the cache in.
+	Dir string
+	// TTL the period when the entry is fresh, the entries never expire if zero.
+	TTL time.Duration
+	// Offline serves the responses from the cache disregarding their freshness, the cache misses yield ErrCacheMiss.
+	Offline bool
+}
+
+// NewCacheClient initialises the cache client which stores the data in the directory.
+func NewCacheClient(c HTTPClient, dir string, ttl time.Duration, offline bool) (*CacheClient, error) {
+	var o *CacheClient
+	err := os.MkdirAll(filepath.Join(dir, cacheDirEntries), 0750)
+	if err == nil {
+		err = os.MkdirAll(filepath.Join(dir, cacheDirBlobs), 0750)
+	}
+	if err == nil {
+		o = &CacheClient{HTTPClient: c, Dir: dir, TTL: ttl, Offline: offline}
+	}
+	return o, err
+}
+
+const (
+	cacheDirEntries = "entries"
+	cacheDirBlobs   = "blobs"
+)
+
+// CacheEntry defines the metadata of the stored response.
+type CacheEntry struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	FetchedAt  time.Time   `json:"fetchedAt"`
+	// BodySHA256 the checksum of the response body which addresses the body in the cache.
+	BodySHA256 string `json:"bodySHA256"`
+}
+
+func (c *CacheClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+func (c *CacheClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != "" {
+		if c.Offline {
+			return nil, fmt.Errorf("%w: method %s is not cacheable", ErrCacheMiss, req.Method)
+		}
+		return c.HTTPClient.Do(req)
+	}
+
+	url := req.URL.String()
+	entry, body, err := c.Lookup(url)
+	cached := err == nil
+	switch {
+	case cached && (c.Offline || c.TTL == 0 || time.Since(entry.FetchedAt) < c.TTL):
+		return entry.response(req, body), nil
+	case c.Offline:
+		return nil, fmt.Errorf("%w: %s", ErrCacheMiss, url)
+	case cached:
+		req = entry.conditional(req)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return resp, err
+	}
+
+	switch {
+	case cached && resp.StatusCode == http.StatusNotModified:
+		// the page is unchanged since the previous fetch, the validators are refreshed
+		_ = resp.Body.Close()
+		if entry.Header == nil {
+			entry.Header = make(http.Header)
+		}
+		for k, v := range resp.Header {
+			entry.Header[k] = v
+		}
+	case resp.StatusCode == http.StatusOK:
+		body, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not read the response from %s: %w", url, err)
+		}
+		entry = CacheEntry{URL: url, StatusCode: resp.StatusCode, Header: resp.Header}
+	default:
+		return resp, nil
+	}
+
+	entry.FetchedAt = time.Now().UTC()
+	if err = c.store(entry, body); err != nil {
+		return nil, fmt.Errorf("could not cache the response from %s: %w", url, err)
+	}
+	return entry.response(req, body), nil
+}
+
+// Lookup reads the cached response for the URL.
+func (c *CacheClient) Lookup(url string) (CacheEntry, []byte, error) {
+	var (
+		entry CacheEntry
+		body  []byte
+	)
+	b, err := os.ReadFile(c.entryPath(url))
+	if err == nil {
+		if err = json.Unmarshal(b, &entry); err == nil {
+			body, err = os.ReadFile(c.blobPath(entry.BodySHA256))
+		}
+	}
+	return entry, body, err
+}
+
+func (c *CacheClient) store(entry CacheEntry, body []byte) error {
+	sum := sha256.Sum256(body)
+	entry.BodySHA256 = hex.EncodeToString(sum[:])
+
+	var err error
+	blob := c.blobPath(entry.BodySHA256)
+	if _, er := os.Stat(blob); er != nil {
+		if err = os.MkdirAll(filepath.Dir(blob), 0750); err == nil {
+			err = writeFileAtomic(blob, body)
+		}
+	}
+
+	if err == nil {
+		var b []byte
+		if b, err = json.Marshal(entry); err == nil {
+			err = writeFileAtomic(c.entryPath(entry.URL), b)
+		}
+	}
+	return err
+}
+
+func (c *CacheClient) entryPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.Dir, cacheDirEntries, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *CacheClient) blobPath(sum string) string {
+	if len(sum) < 2 {
+		return filepath.Join(c.Dir, cacheDirBlobs, sum)
+	}
+	return filepath.Join(c.Dir, cacheDirBlobs, sum[:2], sum)
+}
+
+// conditional returns the copy of the request to revalidate the entry using its ETag and Last-Modified validators.
+func (e CacheEntry) conditional(req *http.Request) *http.Request {
+	var (
+		etag         = e.Header.Get("ETag")
+		lastModified = e.Header.Get("Last-Modified")
+	)
+	if etag == "" && lastModified == "" {
+		return req
+	}
+	o := req.Clone(req.Context())
+	if o.Header == nil {
+		o.Header = make(http.Header)
+	}
+	if etag != "" {
+		o.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		o.Header.Set("If-Modified-Since", lastModified)
+	}
+	return o
+}
+
+func (e CacheEntry) response(req *http.Request, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// writeFileAtomic writes the data to the temporary file and renames it to keep the previous content intact on failure.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err == nil {
+		_, err = f.Write(data)
+		err = errors.Join(err, f.Close())
+		if err == nil {
+			err = os.Rename(f.Name(), path)
+		}
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}
+	return err
+}
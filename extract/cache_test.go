@@ -0,0 +1,172 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"cigarsdb/storage/fs"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheClient(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>foo</html>"))
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	c, err := NewCacheClient(http.DefaultClient, dir, 0, false)
+	assert.NoError(t, err)
+
+	readBody := func(t *testing.T, resp *http.Response) string {
+		t.Helper()
+		b, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		return string(b)
+	}
+
+	t.Run("response is fetched once", func(t *testing.T) {
+		for range 2 {
+			resp, err := c.Get(srv.URL + "/foo")
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "text/html", resp.Header.Get("Content-Type"))
+			assert.Equal(t, "<html>foo</html>", readBody(t, resp))
+		}
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("identical bodies are stored once", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/bar", nil)
+		resp, err := c.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, "<html>foo</html>", readBody(t, resp))
+
+		var blobs int
+		_ = filepath.WalkDir(filepath.Join(dir, cacheDirBlobs), func(_ string, d os.DirEntry, _ error) error {
+			if !d.IsDir() {
+				blobs++
+			}
+			return nil
+		})
+		assert.Equal(t, 1, blobs)
+	})
+
+	t.Run("unsuccessful response is not stored", func(t *testing.T) {
+		resp, err := c.Get(srv.URL + "/missing")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		_, _, err = c.Lookup(srv.URL + "/missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("expired entry is fetched again", func(t *testing.T) {
+		cnt := requests.Load()
+		c := &CacheClient{HTTPClient: http.DefaultClient, Dir: dir, TTL: time.Nanosecond}
+		_, err := c.Get(srv.URL + "/foo")
+		assert.NoError(t, err)
+		assert.Equal(t, cnt+1, requests.Load())
+	})
+
+	t.Run("offline", func(t *testing.T) {
+		cnt := requests.Load()
+		c, err := NewCacheClient(MockHTTP{Err: errors.New("network is down")}, dir, time.Nanosecond, true)
+		assert.NoError(t, err)
+
+		resp, err := c.Get(srv.URL + "/foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "<html>foo</html>", readBody(t, resp))
+
+		_, err = c.Get(srv.URL + "/qux")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+		assert.Equal(t, cnt, requests.Load())
+	})
+}
+
+func TestCacheClient_revalidate(t *testing.T) {
+	const etag = `"v1"`
+	var notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte("<html>foo</html>"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	c, err := NewCacheClient(http.DefaultClient, dir, time.Nanosecond, false)
+	assert.NoError(t, err)
+
+	for range 2 {
+		resp, err := c.Get(srv.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		b, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "<html>foo</html>", string(b))
+	}
+	assert.Equal(t, int32(1), notModified.Load())
+
+	entry, _, err := c.Lookup(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, etag, entry.Header.Get("ETag"))
+}
+
+func TestCacheClient_revalidate_notRewritten(t *testing.T) {
+	const etag = `"v1"`
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.Header().Set("Date", t0.Add(time.Hour).Format(http.TimeFormat))
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Date", t0.Format(http.TimeFormat))
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte("<html>foo</html>"))
+	}))
+	defer srv.Close()
+
+	cache, err := NewCacheClient(http.DefaultClient, t.TempDir(), time.Nanosecond, false)
+	assert.NoError(t, err)
+	m := NewMetadata("foo", "v1.0.0")
+	c := m.Client(cache)
+	w, err := fs.NewClient(t.TempDir())
+	assert.NoError(t, err)
+
+	var ids []string
+	for range 2 {
+		resp, err := c.Get(srv.URL)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		r := storage.Record{Name: "foo", URL: srv.URL}
+		m.Apply(&r)
+		ids, err = w.Write(context.TODO(), []storage.Record{r})
+		assert.NoError(t, err)
+	}
+
+	got, err := w.Read(context.TODO(), ids[0])
+	assert.NoError(t, err)
+	assert.Equal(t, t0, got.Metadata.FetchedAt.UTC())
+}
@@ -0,0 +1,297 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Tracker receives the progress of the detail pages extraction.
+type Tracker interface {
+	// Plan registers the URLs of the detail pages found on the page before they are fetched.
+	Plan(urls ...string)
+	// Track registers the outcome of the detail page extraction.
+	Track(url string, err error)
+}
+
+// Plan registers the URLs in the tracker if the latter is defined.
+func Plan(t Tracker, urls ...string) {
+	if t != nil {
+		t.Plan(urls...)
+	}
+}
+
+// Track registers the outcome in the tracker if the latter is defined.
+func Track(t Tracker, url string, err error) {
+	if t != nil {
+		t.Track(url, err)
+	}
+}
+
+// URLStatus defines the state of the detail page extraction.
+type URLStatus string
+
+const (
+	// URLStatusPending the page was found, but not fetched yet.
+	URLStatusPending URLStatus = "pending"
+	// URLStatusFetched the page was fetched, but the record was not persisted yet.
+	URLStatusFetched URLStatus = "fetched"
+	// URLStatusFailed the page could not be fetched, or parsed.
+	URLStatusFailed URLStatus = "failed"
+	// URLStatusDone the record was persisted.
+	URLStatusDone URLStatus = "done"
+)
+
+// Failure defines the failed attempt to extract the data.
+type Failure struct {
+	Page  uint        `json:"page"`
+	URL   string      `json:"url,omitempty"`
+	Kind  FailureKind `json:"kind,omitempty"`
+	Error string      `json:"error"`
+	// Warning indicates that the record was kept despite the error, see Warning.
+	Warning bool      `json:"warning,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+func newFailure(page uint, url string, err error) Failure {
+	var warning *Warning
+	return Failure{Page: page, URL: url, Kind: ClassifyError(err), Error: err.Error(), Warning: errors.As(err, &warning),
+		At: time.Now().UTC()}
+}
+
+// Checkpoint defines the state of the extraction run which allows to resume it.
+// The per-URL status is kept for the page which is being extracted only.
+type Checkpoint struct {
+	// Source the name of the data source.
+	Source string `json:"source"`
+	// LastCompletedPage the last page which records were fully persisted.
+	LastCompletedPage uint `json:"lastCompletedPage"`
+	// Page the page which is being extracted.
+	Page uint `json:"page,omitempty"`
+	// NextPage the page following the page which is being extracted as reported by the source,
+	// zero if the page is the last one, or the source did not report it.
+	NextPage uint `json:"nextPage,omitempty"`
+	// URLs the status of the detail pages found on the page which is being extracted.
+	URLs map[string]URLStatus `json:"urls,omitempty"`
+	// Failures the history of the run's failures.
+	Failures  []Failure `json:"failures,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	mu *sync.Mutex
+}
+
+// NewCheckpoint initialises the empty checkpoint for the source.
+func NewCheckpoint(source string) *Checkpoint {
+	return &Checkpoint{Source: source, mu: new(sync.Mutex)}
+}
+
+// LoadCheckpoint reads the checkpoint from the file.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	var o = NewCheckpoint("")
+	f, err := os.Open(path)
+	if err == nil {
+		err = json.NewDecoder(f).Decode(o)
+		_ = f.Close()
+	}
+	if err != nil {
+		o = nil
+	}
+	return o, err
+}
+
+// Save persists the checkpoint to the file.
+// The file is replaced atomically to keep the previous state intact in case of failure.
+func (c *Checkpoint) Save(path string) error {
+	c.mu.Lock()
+	c.UpdatedAt = time.Now().UTC()
+	b, err := json.Marshal(c)
+	c.mu.Unlock()
+
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(path), 0750); err == nil {
+			err = writeFileAtomic(path, b)
+		}
+	}
+	return err
+}
+
+// Start marks the beginning of the page extraction.
+// The per-URL status is kept if the page was started before, i.e., when the run is resumed.
+func (c *Checkpoint) Start(page uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Page != page {
+		c.Page = page
+		c.NextPage = 0
+		c.URLs = nil
+	}
+}
+
+// Next registers the page following the page which is being extracted as reported by the source.
+func (c *Checkpoint) Next(page uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.NextPage = page
+}
+
+// Complete marks the page as fully extracted and persisted.
+func (c *Checkpoint) Complete(page uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.LastCompletedPage = page
+	c.Page = 0
+	c.NextPage = 0
+	c.URLs = nil
+}
+
+// Fail registers the failure which is not attributed to the detail page.
+// The cancelled extraction is not registered as the failure.
+func (c *Checkpoint) Fail(page uint, err error) {
+	if isCancelled(err) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Failures = append(c.Failures, newFailure(page, "", err))
+}
+
+// Plan implements the Tracker interface.
+func (c *Checkpoint) Plan(urls ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, u := range urls {
+		if _, ok := c.URLs[u]; !ok {
+			c.setStatus(u, URLStatusPending)
+		}
+	}
+}
+
+// Track implements the Tracker interface.
+// The page which is not a cigar is marked as done because it has no record to persist.
+// The cancelled extraction is not registered as the failure, the page is fetched again on resume.
+// The Warning is registered as the failure without changing the page's status.
+func (c *Checkpoint) Track(url string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var warning *Warning
+	switch {
+	case errors.As(err, &warning):
+		c.Failures = append(c.Failures, newFailure(c.Page, url, err))
+	case err == nil:
+		if c.URLs[url] != URLStatusDone {
+			c.setStatus(url, URLStatusFetched)
+		}
+	case isCancelled(err):
+		c.setStatus(url, URLStatusPending)
+	default:
+		f := newFailure(c.Page, url, err)
+		switch f.Kind {
+		case FailureNotACigar:
+			c.setStatus(url, URLStatusDone)
+		default:
+			c.setStatus(url, URLStatusFailed)
+		}
+		c.Failures = append(c.Failures, f)
+	}
+}
+
+// Done marks the detail pages as extracted and their records as persisted.
+func (c *Checkpoint) Done(urls ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, u := range urls {
+		c.setStatus(u, URLStatusDone)
+	}
+}
+
+func isCancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (c *Checkpoint) setStatus(url string, status URLStatus) {
+	if url == "" {
+		return
+	}
+	if c.URLs == nil {
+		c.URLs = make(map[string]URLStatus)
+	}
+	c.URLs[url] = status
+}
+
+// Pending returns the sorted URLs of the detail pages which records were not persisted.
+// The result is empty if the page which is being extracted did not report its detail pages.
+func (c *Checkpoint) Pending() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var o []string
+	for _, u := range slices.Sorted(maps.Keys(c.URLs)) {
+		if c.URLs[u] != URLStatusDone {
+			o = append(o, u)
+		}
+	}
+	return o
+}
+
+// FailuresSince returns the failures registered since the given moment, e.g., the beginning of the run.
+func (c *Checkpoint) FailuresSince(t time.Time) []Failure {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var o []Failure
+	for _, f := range c.Failures {
+		if !f.At.Before(t) {
+			o = append(o, f)
+		}
+	}
+	return o
+}
+
+// FailureReport defines the summary of the failures.
+type FailureReport struct {
+	Total    int                 `json:"total"`
+	ByKind   map[FailureKind]int `json:"byKind,omitempty"`
+	Failures []Failure           `json:"failures,omitempty"`
+}
+
+// NewFailureReport aggregates the failures by their kind.
+func NewFailureReport(failures []Failure) FailureReport {
+	var o = FailureReport{Total: len(failures), Failures: failures}
+	for _, f := range failures {
+		if o.ByKind == nil {
+			o.ByKind = make(map[FailureKind]int)
+		}
+		o.ByKind[cmp.Or(f.Kind, FailureOther)]++
+	}
+	return o
+}
+
+// Writer wraps the writer to mark the detail pages of the persisted records as done.
+func (c *Checkpoint) Writer(w storage.Writer) storage.Writer {
+	return checkpointWriter{w: w, c: c}
+}
+
+type checkpointWriter struct {
+	w storage.Writer
+	c *Checkpoint
+}
+
+func (w checkpointWriter) Write(ctx context.Context, r []storage.Record) ([]string, error) {
+	ids, err := w.w.Write(ctx, r)
+	if err == nil {
+		var urls = make([]string, 0, len(r))
+		for _, rec := range r {
+			if !rec.IsEmpty() {
+				urls = append(urls, rec.URL)
+			}
+		}
+		w.c.Done(urls...)
+	}
+	return ids, err
+}
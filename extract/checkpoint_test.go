@@ -0,0 +1,114 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockWriter struct {
+	Err error
+}
+
+func (m mockWriter) Write(_ context.Context, r []storage.Record) ([]string, error) {
+	var ids = make([]string, len(r))
+	for i, rec := range r {
+		ids[i] = rec.URL
+	}
+	return ids, m.Err
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.checkpoint")
+	c := NewCheckpoint("foo")
+
+	c.Start(1)
+	c.Plan("https://foo.bar/0", "https://foo.bar/1", "https://foo.bar/2")
+	c.Next(2)
+	c.Track("https://foo.bar/0", nil)
+	c.Track("https://foo.bar/1", errors.New("not found"))
+	c.Track("https://foo.bar/2", nil)
+
+	w := c.Writer(mockWriter{})
+	_, err := w.Write(context.TODO(), []storage.Record{{Name: "0", URL: "https://foo.bar/0"}})
+	assert.NoError(t, err)
+
+	_, err = c.Writer(mockWriter{Err: errors.New("disk full")}).Write(context.TODO(),
+		[]storage.Record{{Name: "2", URL: "https://foo.bar/2"}})
+	assert.Error(t, err)
+
+	assert.NoError(t, c.Save(path))
+
+	got, err := LoadCheckpoint(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", got.Source)
+	assert.Equal(t, uint(1), got.Page)
+	assert.Equal(t, uint(2), got.NextPage)
+	assert.Zero(t, got.LastCompletedPage)
+	assert.Equal(t, []string{"https://foo.bar/1", "https://foo.bar/2"}, got.Pending())
+	assert.Len(t, got.Failures, 1)
+	assert.Equal(t, "https://foo.bar/1", got.Failures[0].URL)
+
+	t.Run("resumed page keeps the status", func(t *testing.T) {
+		got.Start(1)
+		got.Plan("https://foo.bar/0", "https://foo.bar/1", "https://foo.bar/2")
+		assert.Equal(t, []string{"https://foo.bar/1", "https://foo.bar/2"}, got.Pending())
+		assert.Equal(t, uint(2), got.NextPage)
+	})
+
+	t.Run("completed page resets the status", func(t *testing.T) {
+		got.Complete(1)
+		got.Start(2)
+		assert.Equal(t, uint(1), got.LastCompletedPage)
+		assert.Empty(t, got.Pending())
+		assert.Zero(t, got.NextPage)
+	})
+}
+
+func TestLoadCheckpoint(t *testing.T) {
+	got, err := LoadCheckpoint(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+	assert.Nil(t, got)
+}
+
+func TestCheckpoint_failureReport(t *testing.T) {
+	c := NewCheckpoint("foo")
+	c.Fail(1, errors.New("old failure"))
+	since := time.Now().UTC()
+
+	c.Start(2)
+	c.Plan("https://foo.bar/0", "https://foo.bar/1", "https://foo.bar/2")
+	c.Track("https://foo.bar/0", &NotACigarError{URL: "https://foo.bar/0", Kind: "sampler"})
+	c.Track("https://foo.bar/1", &HTTPStatusError{URL: "https://foo.bar/1", StatusCode: http.StatusNotFound})
+	c.Track("https://foo.bar/2", fmt.Errorf("foo: %w", &ParseError{Field: "Ring"}))
+
+	assert.Equal(t, []string{"https://foo.bar/1", "https://foo.bar/2"}, c.Pending(),
+		"the page which is not a cigar is done")
+
+	got := NewFailureReport(c.FailuresSince(since))
+	assert.Equal(t, 3, got.Total)
+	assert.Equal(t, map[FailureKind]int{
+		FailureNotACigar:  1,
+		FailureHTTPStatus: 1,
+		FailureParse:      1,
+	}, got.ByKind)
+}
+
+func TestCheckpoint_cancelled(t *testing.T) {
+	c := NewCheckpoint("foo")
+	c.Start(1)
+	c.Plan("https://foo.bar/0")
+	c.Track("https://foo.bar/0", fmt.Errorf("foo: %w", context.Canceled))
+	c.Fail(1, context.DeadlineExceeded)
+
+	assert.Equal(t, []string{"https://foo.bar/0"}, c.Pending())
+	assert.Equal(t, URLStatusPending, c.URLs["https://foo.bar/0"])
+	assert.Empty(t, c.Failures)
+}
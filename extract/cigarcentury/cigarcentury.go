@@ -17,10 +17,53 @@ import (
 	"golang.org/x/net/html/atom"
 )
 
+// selectors of the cigarcentury.com pages.
+var (
+	selectorProduct     = htmlfilter.MustCompile("div.producto-item")
+	selectorLink        = htmlfilter.MustCompile("a")
+	selectorName        = htmlfilter.MustCompile("h1.nombre-producto")
+	selectorAttribute   = htmlfilter.MustCompile("div.col-12.dato")
+	selectorHidden      = htmlfilter.MustCompile("div.d-none")
+	selectorListItem    = htmlfilter.MustCompile("li")
+	selectorInput       = htmlfilter.MustCompile("input")
+	selectorRating      = htmlfilter.MustCompile("div.calificacion_especializada")
+	selectorRatingValue = htmlfilter.MustCompile("div.calificacion_valor")
+	selectorRatingName  = htmlfilter.MustCompile("div.calificacion_nombre")
+	selectorRatingYear  = htmlfilter.MustCompile("div.calificacion_ano")
+)
+
+func init() {
+	extract.Register(extract.Source{
+		Name:      "cigarcentury",
+		BaseURL:   "https://www.cigarcentury.com",
+		Languages: []string{"en"},
+		Fields: []string{
+			"Name", "URL", "Brand", "Ring", "Length", "Format", "Maker", "ManufactureOrigin", "IsBoxpressed",
+			"WrapperOrigin", "FillerOrigin", "BinderOrigin", "Color", "AromaProfileCommunity", "Strength",
+			"AdditionalNotes", "SpecializedRatings",
+		},
+		Expected: map[extract.PageType][]string{
+			extract.PageDetail: {"Name", "Brand", "Ring", "Length"},
+		},
+		New: func(cfg extract.Config) storage.Reader {
+			return Client{HTTPClient: cfg.HTTPClient, Dumper: cfg.Dumper, Logs: cfg.Logs, Tracker: cfg.Tracker,
+				Workers: cfg.Workers, Provenance: cfg.Provenance, Metadata: cfg.Metadata}
+		},
+	})
+}
+
+// Client defines the client to cigarcentury.com to fetch data from.
 type Client struct {
 	HTTPClient extract.HTTPClient
 	Logs       *slog.Logger
 	Dumper     storage.Writer
+	Tracker    extract.Tracker
+	// Workers the max number of the detail pages fetched concurrently.
+	Workers int
+	// Provenance completes the fields' provenance, it is dropped if nil.
+	Provenance *extract.Provenance
+	// Metadata stamps the records with the extraction metadata.
+	Metadata *extract.Metadata
 }
 
 func (c Client) ReadBulk(ctx context.Context, _, _ uint) (r []storage.Record, nextPage uint, err error) {
@@ -42,6 +85,9 @@ func (c Client) ReadBulk(ctx context.Context, _, _ uint) (r []storage.Record, ne
 	}
 
 	for country, baseURL := range mURLs {
+		if err = ctx.Err(); err != nil {
+			return nil, 0, err
+		}
 		if c.Logs != nil {
 			c.Logs.Info("LP", slog.String("url", baseURL), slog.String("country", country))
 		}
@@ -54,13 +100,10 @@ func (c Client) ReadBulk(ctx context.Context, _, _ uint) (r []storage.Record, ne
 				var doc *html.Node
 				if doc, err = html.Parse(v); err == nil {
 					n := htmlfilter.Node{Node: doc}
-					for el := range n.Find("div.producto-item") {
-						for vv := range el.Find("a") {
-							for _, att := range vv.Attr {
-								if att.Key == "href" {
-									urls = append(urls, att.Val)
-									break
-								}
+					for el := range selectorProduct.Find(n) {
+						for vv := range selectorLink.Find(el) {
+							if u, ok := vv.Href(""); ok {
+								urls = append(urls, u)
 							}
 							break
 						}
@@ -72,27 +115,33 @@ func (c Client) ReadBulk(ctx context.Context, _, _ uint) (r []storage.Record, ne
 		if c.Logs != nil {
 			c.Logs.Info("found urls", slog.Int("count", len(urls)))
 		}
+		extract.Plan(c.Tracker, urls...)
 
-		for _, cigarURL := range urls {
-			rec, er := c.Read(ctx, cigarURL)
-			switch er != nil {
+		results := extract.Map(ctx, extract.Pool{Workers: c.Workers}, urls,
+			func(ctx context.Context, cigarURL string) (storage.Record, error) {
+				rec, er := c.Read(ctx, cigarURL)
+				extract.Track(c.Tracker, cigarURL, er)
+				if er == nil && !rec.IsEmpty() && c.Dumper != nil {
+					if _, er = c.Dumper.Write(ctx, []storage.Record{rec}); er != nil && c.Logs != nil {
+						c.Logs.Info("write record error", slog.Any("error", er),
+							slog.String("url", cigarURL))
+					}
+				}
+				return rec, er
+			})
+		for _, res := range results {
+			switch res.Err != nil {
 			case true:
-				err = errors.Join(err, er)
+				err = errors.Join(err, res.Err)
 			case false:
-				if !rec.IsEmpty() {
-					if c.Dumper != nil {
-						if _, er = c.Dumper.Write(ctx, []storage.Record{rec}); er != nil {
-							err = errors.Join(err, er)
-							if c.Logs != nil {
-								c.Logs.Info("write record error", slog.Any("error", er),
-									slog.String("url", cigarURL))
-							}
-						}
-					}
-					r = append(r, rec)
+				if !res.Value.IsEmpty() {
+					r = append(r, res.Value)
 				}
 			}
 		}
+		if ctx.Err() != nil {
+			return r, nextPage, err
+		}
 	}
 	return r, nextPage, err
 }
@@ -104,14 +153,15 @@ func (c Client) Read(ctx context.Context, id string) (r storage.Record, err erro
 		if doc, err = html.Parse(v); err == nil {
 			n := htmlfilter.Node{Node: doc}
 
-			for el := range n.Find("h1.nombre-producto") {
-				r.Name = el.LastChild.Data
+			for el := range selectorName.Find(n) {
+				r.Name = el.OwnText()
+				r.SetProvenance("Name", storage.Provenance{Selector: "h1.nombre-producto", Raw: r.Name})
 				break
 			}
 
 			var categories = make([]string, 0)
 			var values = make([]*html.Node, 0)
-			for el := range n.Find("div.col-12.dato") {
+			for el := range selectorAttribute.Find(n) {
 				for ch := range el.ChildNodes() {
 					if ch.DataAtom == atom.Div {
 						for _, att := range ch.Attr {
@@ -120,15 +170,15 @@ func (c Client) Read(ctx context.Context, id string) (r storage.Record, err erro
 								case att.Val == "valor descripcion":
 									chS := htmlfilter.Node{Node: ch}
 									var s = make([]string, 0)
-									for el := range chS.Find("div.d-none") {
-										s = append(s, el.LastChild.Data)
+									for el := range selectorHidden.Find(chS) {
+										s = append(s, el.OwnText())
 									}
 									s = flipArr(s)
 									note := strings.Join(s, " ")
 									r.AdditionalNotes = &note
 
 								case att.Val == "nombre":
-									categories = append(categories, ch.FirstChild.Data)
+									categories = append(categories, htmlfilter.Node{Node: ch}.OwnText())
 
 								case strings.HasSuffix(att.Val, "strength-rating-show"):
 									values = append(values, ch)
@@ -147,6 +197,8 @@ func (c Client) Read(ctx context.Context, id string) (r storage.Record, err erro
 		}
 		return storage.Record{}, err
 	})
+	c.Provenance.Apply(&r)
+	c.Metadata.Apply(&r)
 	return r, err
 }
 
@@ -160,10 +212,10 @@ func augmentByCategoryValues(r *storage.Record, categories []string, values []*h
 		case "Brand":
 			b := htmlfilter.Node{values[i]}
 			var tmp = make([]string, 0)
-			for el := range b.Find("li") {
+			for el := range selectorListItem.Find(b) {
 				for c := range el.ChildNodes() {
 					if c.DataAtom == atom.A {
-						tmp = append(tmp, strings.TrimSpace(c.LastChild.Data))
+						tmp = append(tmp, htmlfilter.Node{Node: c}.Text())
 					}
 				}
 			}
@@ -203,13 +255,10 @@ func augmentByCategoryValues(r *storage.Record, categories []string, values []*h
 
 		case "Strength":
 			n := htmlfilter.Node{Node: values[i]}
-			for el := range n.Find("input") {
-				for _, att := range el.Attr {
-					if att.Key == "title" {
-						v := strings.TrimSpace(att.Val)
-						r.Strength = &v
-						break
-					}
+			for el := range selectorInput.Find(n) {
+				if v, ok := el.Attr("title"); ok {
+					v = strings.TrimSpace(v)
+					r.Strength = &v
 				}
 				break
 			}
@@ -250,29 +299,52 @@ func augmentByCategoryValues(r *storage.Record, categories []string, values []*h
 		case "Specialized Ratings":
 			r.SpecializedRatings = make([]storage.SpecializedRating, 0)
 			n := htmlfilter.Node{values[i].Parent}
-			for c := range n.Find("div.calificacion_especializada") {
+			for c := range selectorRating.Find(n) {
 				var rating storage.SpecializedRating
-				for v := range c.Find("div.calificacion_valor") {
+				for v := range selectorRatingValue.Find(c) {
 					rating.RatingOutOf100, _ = strconv.ParseFloat(
-						strings.TrimSuffix(v.LastChild.Data, "%"), 64,
+						strings.TrimSuffix(v.OwnText(), "%"), 64,
 					)
 					break
 				}
-				for v := range c.Find("div.calificacion_nombre") {
-					rating.Who = strings.TrimSpace(v.LastChild.Data)
+				for v := range selectorRatingName.Find(c) {
+					rating.Who = v.OwnText()
 					break
 				}
-				for v := range c.Find("div.calificacion_ano") {
-					rating.Year = strings.TrimSpace(v.LastChild.Data)
+				for v := range selectorRatingYear.Find(c) {
+					rating.Year = v.OwnText()
 					break
 				}
 				r.SpecializedRatings = append(r.SpecializedRatings, rating)
 			}
 		}
+		if field, ok := categoryFields[category]; ok && values[i] != nil {
+			r.SetProvenance(field, storage.Provenance{
+				Selector: "div.col-12.dato div.valor", Label: category, Raw: htmlfilter.Node{Node: values[i]}.Text(),
+			})
+		}
 	}
 	return err
 }
 
+// categoryFields maps the categories to the storage.Record fields.
+var categoryFields = map[string]string{
+	"Origin":              "ManufactureOrigin",
+	"Brand":               "Brand",
+	"Manufacturer":        "Maker",
+	"Wrapper":             "WrapperOrigin",
+	"Binder":              "BinderOrigin",
+	"Filler":              "FillerOrigin",
+	"Vitola":              "Format",
+	"Box-Pressed":         "IsBoxpressed",
+	"Strength":            "Strength",
+	"Flavors":             "AromaProfileCommunity",
+	"Length":              "Length",
+	"Ring Gauge":          "Ring",
+	"Color":               "Color",
+	"Specialized Ratings": "SpecializedRatings",
+}
+
 // expected input ({{.int}})
 func readVotes(s string) int {
 	var tmp = make([]rune, 0, len(s))
@@ -10,8 +10,10 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -21,10 +23,47 @@ import (
 	"golang.org/x/net/html/atom"
 )
 
+// selectors of the cigargeeks.com pages.
+var (
+	selectorBrandsList = htmlfilter.MustCompile("ul#brands_list")
+	selectorListItem   = htmlfilter.MustCompile("li")
+	selectorLink       = htmlfilter.MustCompile("a")
+	selectorRow        = htmlfilter.MustCompile("tr.windowbg")
+	selectorCell       = htmlfilter.MustCompile("td.lefttext")
+	selectorNextPage   = htmlfilter.MustCompile("span.main_icons.next_page")
+)
+
+func init() {
+	extract.Register(extract.Source{
+		Name:      "cigargeeks",
+		BaseURL:   "https://www.cigargeeks.com",
+		Languages: []string{"en"},
+		Fields: []string{
+			"Name", "URL", "Brand", "Ring", "LengthInch", "Format", "ManufactureOrigin",
+			"WrapperOrigin", "FillerOrigin", "BinderOrigin", "Color", "Strength", "AdditionalNotes",
+		},
+		Expected: map[extract.PageType][]string{
+			extract.PageDetail: {"Name", "Brand", "Ring", "LengthInch"},
+		},
+		New: func(cfg extract.Config) storage.Reader {
+			return Client{HTTPClient: cfg.HTTPClient, Dumper: cfg.Dumper, Logs: cfg.Logs, Tracker: cfg.Tracker,
+				Workers: cfg.Workers, Provenance: cfg.Provenance, Metadata: cfg.Metadata}
+		},
+	})
+}
+
+// Client defines the client to cigargeeks.com to fetch data from.
 type Client struct {
 	HTTPClient extract.HTTPClient
 	Logs       *slog.Logger
 	Dumper     storage.Writer
+	Tracker    extract.Tracker
+	// Workers the max number of the detail pages fetched concurrently.
+	Workers int
+	// Provenance completes the fields' provenance, it is dropped if nil.
+	Provenance *extract.Provenance
+	// Metadata stamps the records with the extraction metadata.
+	Metadata *extract.Metadata
 }
 
 const cookie = "SMFCookie895=%7B%220%22%3A133942%2C%221%22%3A%22e30e17daccc11bb313e8a418283f6f3d2743743b0f084407b3" +
@@ -48,9 +87,12 @@ var retries = struct {
 func (c Client) Read(ctx context.Context, id string) (r storage.Record, err error) {
 	var res any
 	res, err = c.processReq(ctx, id, readDetailsPage)
+	err = extract.AttachURL(err, id)
 	if err == nil {
 		r = res.(storage.Record)
 		r.URL = id
+		c.Provenance.Apply(&r)
+		c.Metadata.Apply(&r)
 	}
 
 	var retry bool
@@ -78,13 +120,24 @@ func (c Client) Read(ctx context.Context, id string) (r storage.Record, err erro
 		if c.Logs != nil {
 			c.Logs.Info("delay and retry", slog.String("id", id), slog.String("delay", delay.String()))
 		}
-		time.Sleep(delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return storage.Record{}, ctx.Err()
+		}
 		return c.Read(ctx, id)
 	}
 
 	return r, err
 }
 
+var (
+	xpathAttrLabel = htmlfilter.MustCompileXPath(
+		"(//div[contains(concat(' ', normalize-space(@class), ' '), ' main_section ')])[1]//dt[strong]")
+	xpathAttrName  = htmlfilter.MustCompileXPath("normalize-space(strong)")
+	xpathAttrValue = htmlfilter.MustCompileXPath("following-sibling::*[1][self::dd]/text()")
+)
+
 func readDetailsPage(_ context.Context, v io.ReadCloser) (any, error) {
 	var o = storage.Record{}
 	var err error
@@ -107,87 +160,90 @@ func readDetailsPage(_ context.Context, v io.ReadCloser) (any, error) {
 		const l = 12
 		var attrNames = make([]string, 0, l)
 		var attrVals = make([]string, 0, l)
-		for el := range n.Find("div.main_section") {
-			for attName := range el.Find("dt") {
-				for c := range attName.ChildNodes() {
-					if c.DataAtom == atom.Strong {
-						val := strings.TrimSpace(c.LastChild.Data)
-						attrNames = append(attrNames, val)
-						break
-					}
+		// the attribute's name is the dt's label, its value is the text lines of the following dd
+		for dt := range xpathAttrLabel.All(n) {
+			var lines []string
+			for _, v := range xpathAttrValue.Values(dt) {
+				if v = strings.TrimSpace(v); v != "" {
+					lines = append(lines, v)
 				}
 			}
-			for attVal := range el.Find("dd") {
-				var attrs = make([]string, 0)
-				for c := range attVal.ChildNodes() {
-					if c.DataAtom != atom.Br {
-						if val := strings.TrimSpace(c.Data); val != "" {
-							attrs = append(attrs, val)
-						}
-					}
-				}
-				var buf = new(bytes.Buffer)
-				for i, attr := range attrs {
-					buf.WriteString(attr)
-					if i < len(attrs)-1 {
-						buf.WriteString("\n")
-					}
-				}
-				attrVals = append(attrVals, buf.String())
-			}
-			break
+			attrNames = append(attrNames, xpathAttrName.Value(dt))
+			attrVals = append(attrVals, strings.Join(lines, "\n"))
 		}
 
-		var er error
 		for i, attrName := range attrNames {
 			attrVal := attrVals[i]
 
+			var (
+				er    error
+				field string
+			)
 			switch {
 			case strings.HasPrefix(attrName, "Brand"):
+				field = "Brand"
 				o.Brand = strings.TrimSpace(attrVal)
 
 			case strings.HasPrefix(attrName, "Name"):
+				field = "Name"
 				o.Name = strings.TrimSpace(attrVal)
 
 			case strings.HasPrefix(attrName, "Length"):
+				field = "LengthInch"
 				if o.LengthInch, er = strconv.ParseFloat(attrVal, 64); er != nil {
-					err = errors.Join(err, fmt.Errorf("could not parse %s as float: %w", attrName, er))
+					err = errors.Join(err, &extract.ParseError{
+						Field: "LengthInch", Selector: "div.main_section dd", Value: attrVal, Err: er,
+					})
 				}
 
 			case strings.HasPrefix(attrName, "Ring Gauge"):
+				field = "Ring"
 				if o.Ring, er = strconv.ParseFloat(attrVal, 64); er != nil {
-					err = errors.Join(err, fmt.Errorf("could not parse %s as float: %w", attrName, er))
+					err = errors.Join(err, &extract.ParseError{
+						Field: "Ring", Selector: "div.main_section dd", Value: attrVal, Err: er,
+					})
 				}
 			case strings.HasPrefix(attrName, "Country of Origin"):
+				field = "ManufactureOrigin"
 				o.ManufactureOrigin = strings.TrimSpace(attrVal)
 
 			case strings.Contains(attrName, "Filler"):
+				field = "FillerOrigin"
 				o.FillerOrigin = readTobaccoOrigin(attrVal)
 
 			case strings.Contains(attrName, "Binder"):
+				field = "BinderOrigin"
 				o.BinderOrigin = readTobaccoOrigin(attrVal)
 
 			case strings.Contains(attrName, "Wrapper"):
+				field = "WrapperOrigin"
 				o.WrapperOrigin = readTobaccoOrigin(attrVal)
 
 			case strings.Contains(attrName, "Color"):
+				field = "Color"
 				if val := strings.TrimSpace(attrVal); val != "" {
 					o.Color = &val
 				}
 
 			case strings.Contains(attrName, "Strength"):
+				field = "Strength"
 				if val := strings.TrimSpace(attrVal); val != "" {
 					o.Strength = &val
 				}
 
 			case strings.Contains(attrName, "Shape"):
+				field = "Format"
 				o.Format = strings.TrimSpace(attrVal)
 
 			case strings.Contains(attrName, "Notes"):
+				field = "AdditionalNotes"
 				if val := strings.TrimSpace(attrVal); val != "" {
 					o.AdditionalNotes = &val
 				}
 			}
+			if er == nil && field != "" && strings.TrimSpace(attrVal) != "" {
+				o.SetProvenance(field, storage.Provenance{Selector: "div.main_section dd", Label: attrName, Raw: attrVal})
+			}
 		}
 	}
 	return o, err
@@ -221,11 +277,11 @@ func (c Client) ReadBulk(ctx context.Context, _, page uint) (r []storage.Record,
 		var doc *html.Node
 		if doc, err = html.Parse(v); err == nil {
 			n := htmlfilter.Node{Node: doc}
-			for tab := range n.Find("ul#brands_list") {
-				for li := range tab.Find("li") {
-					for el := range li.Find("a") {
+			for tab := range selectorBrandsList.Find(n) {
+				for li := range selectorListItem.Find(tab) {
+					for el := range selectorLink.Find(li) {
 						if el.DataAtom == atom.A {
-							brands = append(brands, strings.TrimSpace(el.LastChild.Data))
+							brands = append(brands, el.Text())
 						}
 					}
 				}
@@ -234,7 +290,6 @@ func (c Client) ReadBulk(ctx context.Context, _, page uint) (r []storage.Record,
 		return brands, err
 	})
 
-	var mu = new(sync.Mutex)
 	switch err == nil {
 	case true:
 		if len(brands) > 0 {
@@ -247,67 +302,44 @@ func (c Client) ReadBulk(ctx context.Context, _, page uint) (r []storage.Record,
 				}
 			}
 
+			// the cigars' URLs are collected before the extraction
+			// to report the complete list of the page's URLs to the tracker
+			var cigarURLs []string
 			p := 1
-			var stop bool
-			for !stop {
-				var cigarURLs map[string]struct{}
+			for {
+				var urls map[string]struct{}
 				var hasNextPage bool
-				cigarURLs, err, hasNextPage = c.readCigarURLs(ctx, queryBrands.String(), p)
+				urls, err, hasNextPage = c.readCigarURLs(ctx, queryBrands.String(), p)
 				if err != nil {
-					stop = true
 					break
 				}
-				// if c.Logs != nil {
-				// 	c.Logs.Info("fetch cigars",
-				// 		slog.Int("cntBrands", len(brands)),
-				// 		slog.Int("cntCigarURLs", len(cigarURLs)),
-				// 		slog.Int("page", p),
-				// 		slog.Int("globalPage", int(page)),
-				// 		slog.Int("skip", skip),
-				// 	)
-				// }
-
-				if hasNextPage {
-					p++
-				} else {
-					stop = true
+				cigarURLs = append(cigarURLs, slices.Sorted(maps.Keys(urls))...)
+				if !hasNextPage {
+					break
 				}
+				p++
+			}
 
-				maxN := len(cigarURLs)
-				var cigarPageProcessed = make(chan struct{}, maxN)
-				for cigarURL := range cigarURLs {
-					go func() {
-						rec, er := c.Read(ctx, cigarURL)
-						mu.Lock()
-						switch er != nil {
-						case true:
-							err = errors.Join(err, er)
-						case false:
-							if !rec.IsEmpty() {
-								r = append(r, rec)
-							}
-						}
-						mu.Unlock()
+			if err == nil {
+				extract.Plan(c.Tracker, cigarURLs...)
+			}
 
+			if err == nil {
+				results := extract.Map(ctx, extract.Pool{Workers: c.Workers}, cigarURLs,
+					func(ctx context.Context, cigarURL string) (storage.Record, error) {
+						rec, er := c.Read(ctx, cigarURL)
+						extract.Track(c.Tracker, cigarURL, er)
 						if er == nil && c.Dumper != nil && !rec.IsEmpty() {
-							// if c.Logs != nil {
-							// 	c.Logs.Info("dump fetched cigars record",
-							// 		slog.String("name", rec.Name),
-							// 		slog.String("brand", rec.Brand),
-							// 	)
-							// }
 							_, _ = c.Dumper.Write(ctx, []storage.Record{rec})
 						}
-						cigarPageProcessed <- struct{}{}
-					}()
-				}
-				for maxN > 0 {
-					<-cigarPageProcessed
-					maxN--
-				}
-				if err != nil {
-					break
+						return rec, er
+					})
+				for _, rec := range extract.Values(results) {
+					if !rec.IsEmpty() {
+						r = append(r, rec)
+					}
 				}
+				err = extract.JoinErrors(results)
 			}
 
 			if err == nil && len(brands) == itemsPerPage {
@@ -329,40 +361,7 @@ func (c Client) ReadBulk(ctx context.Context, _, page uint) (r []storage.Record,
 
 func (c Client) processReq(ctx context.Context, url string,
 	fn func(ctx context.Context, v io.ReadCloser) (any, error)) (any, error) {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		err = fmt.Errorf("could not create request: %w", err)
-	} else {
-		req.Header.Add("Cookie", cookie)
-
-		var resp *http.Response
-		resp, err = c.HTTPClient.Do(req)
-
-		switch err == nil {
-		case true:
-			o, er := fn(ctx, resp.Body)
-			_ = resp.Body.Close()
-			return o, er
-
-		case false:
-			var respBytes []byte
-			var er error
-			if resp != nil {
-				respBytes, er = io.ReadAll(resp.Body)
-				switch er == nil {
-				case true:
-					err = fmt.Errorf("error reading %s, body: %s, error: %w", url, respBytes, err)
-				case false:
-					err = fmt.Errorf("error reading %s, error: %w", url, err)
-					err = errors.Join(err, fmt.Errorf("could node read the response: %w", er))
-				}
-			} else {
-				err = fmt.Errorf("error reading %s, error: %w", url, err)
-			}
-		}
-	}
-
-	return nil, err
+	return extract.ProcessReq(ctx, c.HTTPClient, url, http.Header{"Cookie": []string{cookie}}, fn)
 }
 
 func (c Client) readCigarURLs(ctx context.Context, brandsQuery string, p int) (
@@ -375,8 +374,8 @@ func (c Client) readCigarURLs(ctx context.Context, brandsQuery string, p int) (
 		var doc *html.Node
 		if doc, err = html.Parse(v); err == nil {
 			n := htmlfilter.Node{Node: doc}
-			for row := range n.Find("tr.windowbg") {
-				for col := range row.Find("td.lefttext") {
+			for row := range selectorRow.Find(n) {
+				for col := range selectorCell.Find(row) {
 					for c := range col.ChildNodes() {
 						if c.DataAtom == atom.A {
 							for _, attr := range c.Attr {
@@ -399,7 +398,7 @@ func (c Client) readCigarURLs(ctx context.Context, brandsQuery string, p int) (
 				}
 			}
 
-			for _ = range n.Find("span.main_icons.next_page") {
+			for _ = range selectorNextPage.Find(n) {
 				nextPageExists = true
 				break
 			}
@@ -3,7 +3,9 @@ package cigargeeks
 import (
 	"cigarsdb/storage"
 	"context"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -44,3 +46,35 @@ func TestClient_ReadBulk(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, r)
 }
+
+func Test_readDetailsPage(t *testing.T) {
+	const page = `<html><body><div class="windowbg main_section"><dl>
+<dt><strong>Brand:</strong></dt><dd>5 Vegas</dd>
+<dt><strong>Name:</strong></dt><dd>Nicaragua Churchill LE</dd>
+<dt><strong>Length:</strong></dt><dd>7</dd>
+<dt><strong>Ring Gauge:</strong></dt><dd>49</dd>
+<dt><strong>Wrapper:</strong></dt><dd>Ecuador<br>Habano</dd>
+<dt><strong>Color:</strong></dt><dd></dd>
+<dt><strong>Strength:</strong></dt><dd>Medium</dd>
+</dl></div></body></html>`
+
+	got, err := readDetailsPage(context.TODO(), io.NopCloser(strings.NewReader(page)))
+	assert.NoError(t, err)
+	wantStrength := "Medium"
+	assert.Equal(t, storage.Record{
+		Name:          "Nicaragua Churchill LE",
+		Brand:         "5 Vegas",
+		Ring:          49,
+		LengthInch:    7,
+		WrapperOrigin: []string{"Ecuador", "Habano"},
+		Strength:      &wantStrength,
+		Provenance: map[string]storage.Provenance{
+			"Brand":         {Selector: "div.main_section dd", Label: "Brand:", Raw: "5 Vegas"},
+			"Name":          {Selector: "div.main_section dd", Label: "Name:", Raw: "Nicaragua Churchill LE"},
+			"LengthInch":    {Selector: "div.main_section dd", Label: "Length:", Raw: "7"},
+			"Ring":          {Selector: "div.main_section dd", Label: "Ring Gauge:", Raw: "49"},
+			"WrapperOrigin": {Selector: "div.main_section dd", Label: "Wrapper:", Raw: "Ecuador\nHabano"},
+			"Strength":      {Selector: "div.main_section dd", Label: "Strength:", Raw: "Medium"},
+		},
+	}, got)
+}
@@ -9,29 +9,86 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
-	"sync"
 
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 )
 
+// selectors of the cigarworld.de pages.
+var (
+	selectorTastingTab       = htmlfilter.MustCompile("div#tab-pane-tasting")
+	selectorParagraph        = htmlfilter.MustCompile("p")
+	selectorAromaImage       = htmlfilter.MustCompile("canvas.aromaimg")
+	selectorBody             = htmlfilter.MustCompile("body")
+	selectorDescription      = htmlfilter.MustCompile("div.contentpage__content")
+	selectorPrice            = htmlfilter.MustCompile("span.preis")
+	selectorSpan             = htmlfilter.MustCompile("span")
+	selectorUnits            = htmlfilter.MustCompile("span.einheitlabel")
+	selectorDataTab          = htmlfilter.MustCompile("div#tab-pane-data")
+	selectorAttributeGroup   = htmlfilter.MustCompile("div.VariantInfo-item")
+	selectorAttribute        = htmlfilter.MustCompile("div.ws-g.ws-c")
+	selectorAttributeName    = htmlfilter.MustCompile("div.VariantInfo-itemName")
+	selectorAttributeValue   = htmlfilter.MustCompile("div.VariantInfo-itemValue")
+	selectorName             = htmlfilter.MustCompile("h1.h-alt")
+	selectorSearchResult     = htmlfilter.MustCompile("div.ws-g.search-result")
+	selectorSearchResultItem = htmlfilter.MustCompile("div.search-result-item")
+	selectorSearchResultLink = htmlfilter.MustCompile("a.search-result-item-inner")
+	selectorPagination       = htmlfilter.MustCompile("select#pagination_select")
+	selectorOption           = htmlfilter.MustCompile("option")
+)
+
+func init() {
+	extract.Register(extract.Source{
+		Name:      "cigarworld",
+		BaseURL:   baseURL,
+		Languages: []string{"de", "en"},
+		Fields: []string{
+			"Name", "URL", "Brand", "Series", "Details",
+			"Diameter", "Ring", "Length", "LengthInch", "Format",
+			"Maker", "TypeOfManufacturing", "IsBoxpressed",
+			"WrapperOrigin", "WrapperProperty", "WrapperTobaccoVariety",
+			"FillerOrigin", "FillerProperty", "FillerTobaccoVariety",
+			"BinderOrigin", "BinderProperty", "BinderTobaccoVariety",
+			"IsFlavoured", "AromaProfileCommunity", "Price",
+		},
+		Expected: map[extract.PageType][]string{
+			extract.PageDetail: {"Name", "Brand", "Ring", "Length", "Price"},
+		},
+		New: func(cfg extract.Config) storage.Reader {
+			return Client{HTTPClient: cfg.HTTPClient, Dumper: cfg.Dumper, Logs: cfg.Logs, Tracker: cfg.Tracker,
+				Workers: cfg.Workers, Provenance: cfg.Provenance, Metadata: cfg.Metadata}
+		},
+	})
+}
+
 // Client defines the client to cigarworld.de to fetch data from.
 type Client struct {
 	HTTPClient extract.HTTPClient
 	Dumper     storage.Writer
 	Logs       *slog.Logger
+	Tracker    extract.Tracker
+	// Workers the max number of the detail pages fetched concurrently.
+	Workers int
+	// Provenance completes the fields' provenance, it is dropped if nil.
+	Provenance *extract.Provenance
+	// Metadata stamps the records with the extraction metadata.
+	Metadata *extract.Metadata
 }
 
-func (c Client) Read(_ context.Context, id string) (r storage.Record, err error) {
+func (c Client) Read(ctx context.Context, id string) (r storage.Record, err error) {
+	if kind := notACigarKind(id); kind != "" {
+		return r, &extract.NotACigarError{URL: id, Kind: kind}
+	}
 	var resp *http.Response
-	if resp, err = c.HTTPClient.Get(id); err == nil {
-		r.URL = id
-		err = c.readDetailsPage(resp.Body, &r)
+	if resp, err = extract.Get(ctx, c.HTTPClient, id); err == nil {
+		if err = extract.CheckStatus(resp, id); err == nil {
+			r.URL = id
+			err = extract.AttachURL(c.readDetailsPage(resp.Body, &r), id)
+		}
 		_ = resp.Body.Close()
 	} else {
 		r = storage.Record{}
@@ -45,11 +102,25 @@ func (c Client) readDetailsPage(v io.Reader, o *storage.Record) error {
 	if doc, err = html.Parse(v); err == nil {
 		n := htmlfilter.Node{Node: doc}
 		o.Name = readName(n)
+		o.SetProvenance("Name", storage.Provenance{Selector: "h1.h-alt", Raw: o.Name})
 		err = readAttributes(n, o)
 		err = errors.Join(err, readPrice(n, o))
 		readDescription(n, o)
 		readAromaProfileCommunity(n, o)
 	}
+	c.Provenance.Apply(o)
+	c.Metadata.Apply(o)
+
+	// the record is kept if only its optional attributes could not be parsed
+	var warnings []error
+	warnings, err = extract.SplitWarnings(err)
+	for _, w := range warnings {
+		w = extract.AttachURL(w, o.URL)
+		if c.Logs != nil {
+			c.Logs.Warn("could not parse the attribute", slog.Any("error", w))
+		}
+		extract.Track(c.Tracker, o.URL, w)
+	}
 	return err
 }
 
@@ -57,10 +128,9 @@ func readAromaProfileCommunity(n htmlfilter.Node, o *storage.Record) {
 	var aromaWeights []float64
 	var dataRub bool
 	var cntOfVotes int
-	for nn := range n.Find("div#tab-pane-tasting") {
-		for nnn := range nn.Find("p") {
-			if nnn.LastChild != nil {
-				s := strings.TrimSpace(nnn.LastChild.Data)
+	for nn := range selectorTastingTab.Find(n) {
+		for nnn := range selectorParagraph.Find(nn) {
+			if s := nnn.Text(); s != "" {
 				for i, el := range s {
 					if el == '(' {
 						var err error
@@ -74,14 +144,12 @@ func readAromaProfileCommunity(n htmlfilter.Node, o *storage.Record) {
 			break
 		}
 
-		for nnn := range nn.Find("canvas.aromaimg") {
-			for _, att := range nnn.Attr {
-				switch att.Key {
-				case "data-content":
-					aromaWeights = readAromaWeights(att.Val)
-				case "data-rub":
-					dataRub = strings.TrimSpace(att.Val) == "t"
-				}
+		for nnn := range selectorAromaImage.Find(nn) {
+			if v, ok := nnn.Attr("data-content"); ok {
+				aromaWeights = readAromaWeights(v)
+			}
+			if v, ok := nnn.Attr("data-rub"); ok {
+				dataRub = strings.TrimSpace(v) == "t"
 			}
 			break
 		}
@@ -89,7 +157,7 @@ func readAromaProfileCommunity(n htmlfilter.Node, o *storage.Record) {
 	}
 
 	if len(aromaWeights) > 0 {
-		for nn := range n.Find("body") {
+		for nn := range selectorBody.Find(n) {
 			var found bool
 			for nnn := range nn.ChildNodes() {
 				if !found && nnn.DataAtom == atom.Script {
@@ -192,8 +260,8 @@ func readAromaCat(s string, filter string) []string {
 }
 
 func readDescription(n htmlfilter.Node, o *storage.Record) {
-	for nn := range n.Find("div.contentpage__content") {
-		if tmp := htmlfilter.InnerHTML(nn.Node); tmp != "" {
+	for nn := range selectorDescription.Find(n) {
+		if tmp := nn.Markdown(); tmp != "" {
 			o.Details = map[string]string{"description": tmp}
 		}
 		break
@@ -203,12 +271,13 @@ func readDescription(n htmlfilter.Node, o *storage.Record) {
 func readPrice(n htmlfilter.Node, o *storage.Record) error {
 	var err error
 	var costs float64
-	for nn := range n.Find("span.preis") {
-		for nn = range nn.Find("span") {
-			for _, att := range nn.Attr {
-				if att.Key == "data-eurval" {
-					costs, err = strconv.ParseFloat(att.Val, 64)
-					break
+	var raw string
+	for nn := range selectorPrice.Find(n) {
+		for nn = range selectorSpan.Find(nn) {
+			if v, ok := nn.Attr("data-eurval"); ok {
+				raw = v
+				if costs, err = strconv.ParseFloat(v, 64); err != nil {
+					err = &extract.ParseError{Field: "Price", Selector: "span.preis span", Value: v, Err: err}
 				}
 			}
 			break
@@ -216,24 +285,25 @@ func readPrice(n htmlfilter.Node, o *storage.Record) error {
 		break
 	}
 	if err == nil {
-		for nn := range n.Find("span.einheitlabel") {
+		for nn := range selectorUnits.Find(n) {
 			if nn.FirstChild != nil {
-				cntUnitsStr := strings.SplitN(strings.TrimSpace(nn.FirstChild.Data), "er", 2)[0]
+				// the text is normalised, hence NBSP is replaced with the space
+				cntUnitsStr := strings.SplitN(nn.Text(), "er", 2)[0]
 				cntUnitsStr = strings.SplitN(cntUnitsStr, " ", 2)[0]
-				// treat NBSP
-				// https://www.compart.com/en/unicode/U+00A0
-				cntUnitsStr = strings.SplitN(cntUnitsStr, "\u00a0", 2)[0]
-				cntUnitsStr = strings.TrimSpace(cntUnitsStr)
 
 				var cntUnits int
-				if cntUnits, err = strconv.Atoi(cntUnitsStr); err == nil {
-					if cntUnits > 0 {
-						o.Price = float64(int(costs*100) / cntUnits)
-						o.Price = o.Price / 100
-
-					} else {
-						err = fmt.Errorf("could not define price")
-					}
+				if cntUnits, err = strconv.Atoi(cntUnitsStr); err == nil && cntUnits <= 0 {
+					err = errors.New("number of units must be positive")
+				}
+				switch err == nil {
+				case true:
+					o.Price = float64(int(costs*100) / cntUnits)
+					o.Price = o.Price / 100
+					o.SetProvenance("Price", storage.Provenance{
+						Selector: "span.preis span", Label: "data-eurval", Raw: raw,
+					})
+				case false:
+					err = &extract.ParseError{Field: "Price", Selector: "span.einheitlabel", Value: cntUnitsStr, Err: err}
 				}
 			}
 			break
@@ -245,40 +315,22 @@ func readPrice(n htmlfilter.Node, o *storage.Record) error {
 
 func readAttributes(n htmlfilter.Node, o *storage.Record) error {
 	var err error
-	for nn := range n.Find("div#tab-pane-data") {
-		for nnn := range nn.Find("div.VariantInfo-item") {
-			for attr := range nnn.Find("div.ws-g.ws-c") {
+	for nn := range selectorDataTab.Find(n) {
+		for nnn := range selectorAttributeGroup.Find(nn) {
+			for attr := range selectorAttribute.Find(nnn) {
 				var k, v string
-				for attrK := range attr.Find("div.VariantInfo-itemName") {
-					if attrK.LastChild != nil {
-						k = attrK.LastChild.Data
-					}
+				for attrK := range selectorAttributeName.Find(attr) {
+					k = attrK.Text()
 					break
 				}
-				for attrV := range attr.Find("div.VariantInfo-itemValue") {
+				for attrV := range selectorAttributeValue.Find(attr) {
 					switch k {
 					case "Länge", "Length", "Ringmaß / Durchmesser", "Ring / Diameter":
-						val := attrV.Node
-						if val.FirstChild != nil {
-							val = val.FirstChild
-							for val.LastChild != nil {
-								val = val.LastChild
-								if val.DataAtom == 0 {
-									v = val.Data
-									break
-								}
-							}
-						}
+						// the value is given in both units: inches / cm
+						v = htmlfilter.Node{Node: attrV.FirstChild}.Text()
 						err = errors.Join(err, setAttribute(o, k, v))
 					}
-					val := attrV.Node
-					for val.LastChild != nil {
-						val = val.LastChild
-						if val.DataAtom == 0 {
-							v = val.Data
-							break
-						}
-					}
+					v = htmlfilter.Node{Node: attrV.LastChild}.Text()
 					break
 				}
 				err = errors.Join(err, setAttribute(o, k, v))
@@ -290,104 +342,124 @@ func readAttributes(n htmlfilter.Node, o *storage.Record) error {
 }
 
 func setAttribute(o *storage.Record, k string, v string) error {
-	var err error
+	var (
+		err   error
+		field string
+	)
 	switch k {
 	case "Brand", "Marke":
-		o.Brand = v
+		o.Brand, field = v, "Brand"
 
 	case "Size", "Format":
-		o.Format = v
+		o.Format, field = v, "Format"
 
 	case "Produkt", "Item":
-		o.Series = v
+		o.Series, field = v, "Series"
 
 	case "Fabrication", "Herstellungsart":
-		o.TypeOfManufacturing = &v
+		o.TypeOfManufacturing, field = &v, "TypeOfManufacturing"
 
 	case "Aromatisiert":
 		switch v {
 		case "nein", "Nein":
-			o.IsFlavoured = pointer(false)
+			o.IsFlavoured, field = pointer(false), "IsFlavoured"
 		case "ja", "Ja":
-			o.IsFlavoured = pointer(true)
+			o.IsFlavoured, field = pointer(true), "IsFlavoured"
 		}
 
 	case "Flavoured":
 		switch v {
 		case "no", "No":
-			o.IsFlavoured = pointer(false)
+			o.IsFlavoured, field = pointer(false), "IsFlavoured"
 		case "yes", "Yes":
-			o.IsFlavoured = pointer(true)
+			o.IsFlavoured, field = pointer(true), "IsFlavoured"
 		}
 
 	case "Boxpressed":
 		switch v {
 		case "no", "No", "nein", "Nein":
-			o.IsBoxpressed = pointer(false)
+			o.IsBoxpressed, field = pointer(false), "IsBoxpressed"
 		case "yes", "Yes", "ja", "Ja":
-			o.IsBoxpressed = pointer(true)
+			o.IsBoxpressed, field = pointer(true), "IsBoxpressed"
 		}
 
 	case "Tabacalera":
-		o.Maker = &v
+		o.Maker, field = &v, "Maker"
 
 	case "Binder origin", "Umblatt Land":
-		o.BinderOrigin = splitCommaseparatedVals(v)
+		o.BinderOrigin, field = splitCommaseparatedVals(v), "BinderOrigin"
 
 	case "Outer leaf tobacco variety", "Umblatt Tabaksorte":
-		o.BinderTobaccoVariety = []string{v}
+		o.BinderTobaccoVariety, field = []string{v}, "BinderTobaccoVariety"
 
 	case "Outer leaf tobacco property", "Umblatt Eigenschaft":
-		o.BinderProperty = []string{v}
+		o.BinderProperty, field = []string{v}, "BinderProperty"
 
 	case "Filler origin", "Einlage Land":
-		o.FillerOrigin = splitCommaseparatedVals(v)
+		o.FillerOrigin, field = splitCommaseparatedVals(v), "FillerOrigin"
 
 	case "Einlage Tabaksorte":
-		o.FillerTobaccoVariety = []string{v}
+		o.FillerTobaccoVariety, field = []string{v}, "FillerTobaccoVariety"
 
 	case "Einlage Eigenschaft":
-		o.FillerProperty = []string{v}
+		o.FillerProperty, field = []string{v}, "FillerProperty"
 
 	case "Wrapper origin", "Deckblatt Land":
-		o.WrapperOrigin = splitCommaseparatedVals(v)
+		o.WrapperOrigin, field = splitCommaseparatedVals(v), "WrapperOrigin"
 
 	case "Topsheet / -leave tobacco variety", "Deckblatt Tabaksorte":
-		o.WrapperTobaccoVariety = []string{v}
+		o.WrapperTobaccoVariety, field = []string{v}, "WrapperTobaccoVariety"
 
 	case "Topsheet / -leave property", "Deckblatt Eigenschaft":
-		o.WrapperProperty = []string{v}
+		o.WrapperProperty, field = []string{v}, "WrapperProperty"
 
 	case "Length", "Länge":
 		// one of may be missing
 		var val float64
 		if val, err = readFloat(v); err != nil {
-			err = nil
-			log.Printf("error parsing value %s of the attribute %s\n", v, k)
+			err = newParseWarning(k, v, err)
 		}
 		switch {
 		case strings.HasSuffix(v, "inches"):
-			o.LengthInch = val
+			o.LengthInch, field = val, "LengthInch"
 
 		case strings.HasSuffix(v, "cm"):
-			o.Length = cm2mm(val)
+			o.Length, field = cm2mm(val), "Length"
 		}
 
 	case "Ring / Diameter", "Ringmaß / Durchmesser":
 		// one of may be missing
 		var val float64
 		if val, err = readFloat(v); err != nil {
-			err = nil
-			log.Printf("error parsing value %s of the attribute %s\n", v, k)
+			err = newParseWarning(k, v, err)
 		}
 		switch {
 		case strings.HasSuffix(v, "cm"):
-			o.Diameter = cm2mm(val)
+			o.Diameter, field = cm2mm(val), "Diameter"
 
 		default:
-			o.Ring = val
+			o.Ring, field = val, "Ring"
 		}
 	}
+	if err == nil && field != "" {
+		o.SetProvenance(field, storage.Provenance{Selector: "div.VariantInfo-itemValue", Label: k, Raw: v})
+	}
+	return err
+}
+
+// newParseError defines the error of the attribute's value parsing, the missing value is not an error.
+func newParseError(k, v string, err error) error {
+	if strings.TrimSpace(v) == "" {
+		return nil
+	}
+	return &extract.ParseError{Field: k, Selector: "div.VariantInfo-itemValue", Value: v, Err: err}
+}
+
+// newParseWarning defines the error of the optional attribute's value parsing which does not fail the record.
+func newParseWarning(k, v string, err error) error {
+	if err = newParseError(k, v, err); err != nil {
+		err = &extract.Warning{Err: err}
+	}
 	return err
 }
 
@@ -420,10 +492,8 @@ func pointer[V bool | int | string](v V) *V {
 
 func readName(n htmlfilter.Node) string {
 	var s string
-	for nn := range n.Find("h1.h-alt") {
-		if nn.LastChild != nil {
-			s = nn.LastChild.Data
-		}
+	for nn := range selectorName.Find(n) {
+		s = nn.OwnText()
 		break
 	}
 	return s
@@ -439,9 +509,11 @@ func (c Client) ReadBulk(ctx context.Context, _, page uint) (r []storage.Record,
 		pages     map[string]string
 		pageQuery string
 	)
-	resp, err = c.HTTPClient.Get(baseURL + "/zigarren")
+	resp, err = extract.Get(ctx, c.HTTPClient, baseURL+"/zigarren")
 	if err == nil {
-		pages, err = newPaginator(resp.Body)
+		if err = extract.CheckStatus(resp, baseURL+"/zigarren"); err == nil {
+			pages, err = newPaginator(resp.Body)
+		}
 		_ = resp.Body.Close()
 		if err == nil {
 			var ok bool
@@ -454,8 +526,11 @@ func (c Client) ReadBulk(ctx context.Context, _, page uint) (r []storage.Record,
 	// read the urls to the Brands, or items present on the given page
 	var candidateURLPaths []string
 	if err == nil {
-		if resp, err = c.HTTPClient.Get(fmt.Sprintf("%s/zigarren?%s", baseURL, pageQuery)); err == nil {
-			candidateURLPaths, err = newCandidateURLPaths(resp.Body, skipURL)
+		u := fmt.Sprintf("%s/zigarren?%s", baseURL, pageQuery)
+		if resp, err = extract.Get(ctx, c.HTTPClient, u); err == nil {
+			if err = extract.CheckStatus(resp, u); err == nil {
+				candidateURLPaths, err = newCandidateURLPaths(resp.Body, skipURL)
+			}
 			_ = resp.Body.Close()
 		}
 	}
@@ -465,8 +540,8 @@ func (c Client) ReadBulk(ctx context.Context, _, page uint) (r []storage.Record,
 		r, err = c.extractRecords(ctx, candidateURLPaths)
 	}
 
-	// increment paginator
-	if err == nil {
+	// increment paginator, the next page is reported with the partial result to resume the run from it
+	if pageQuery != "" {
 		if _, ok := pages[fmt.Sprintf("%d", page+1)]; ok {
 			nextPage = page + 1
 		}
@@ -477,11 +552,26 @@ func (c Client) ReadBulk(ctx context.Context, _, page uint) (r []storage.Record,
 
 func (c Client) extractRecords(ctx context.Context, candidateURLPaths []string) (
 	r []storage.Record, err error) {
-	var mu = new(sync.Mutex)
+	// the candidates are resolved to the detail pages before the extraction
+	// to report the complete list of the page's URLs to the tracker
+	type candidate struct {
+		url         string
+		body        []byte
+		variantURLs []string
+	}
+	var (
+		candidates = make([]candidate, 0, len(candidateURLPaths))
+		urls       = make([]string, 0, len(candidateURLPaths))
+	)
 	for _, s := range candidateURLPaths {
 		candidateURL := baseURL + s
 
-		resp, er := c.HTTPClient.Get(candidateURL)
+		resp, er := extract.Get(ctx, c.HTTPClient, candidateURL)
+		if er == nil {
+			if er = extract.CheckStatus(resp, candidateURL); er != nil {
+				_ = resp.Body.Close()
+			}
+		}
 		if er != nil {
 			err = errors.Join(err, fmt.Errorf("error fetching %s: %w", candidateURL, er))
 			return
@@ -499,44 +589,55 @@ func (c Client) extractRecords(ctx context.Context, candidateURLPaths []string)
 			err = errors.Join(err, fmt.Errorf("could not process the candidate page: %w", er))
 			return
 		}
-		u = filterBulkURLs(u)
 
-		switch len(u) {
+		cand := candidate{url: candidateURL}
+		switch u = filterBulkURLs(u); len(u) {
+		case 0:
+			cand.body = body
+			urls = append(urls, candidateURL)
+		default:
+			for _, url := range u {
+				cand.variantURLs = append(cand.variantURLs, baseURL+url)
+			}
+			urls = append(urls, cand.variantURLs...)
+		}
+		candidates = append(candidates, cand)
+	}
+	extract.Plan(c.Tracker, urls...)
+
+	for _, cand := range candidates {
+		switch len(cand.variantURLs) {
 		case 0:
-			var rec storage.Record
-			er := c.readDetailsPage(bytes.NewReader(body), &rec)
+			var rec = storage.Record{URL: cand.url}
+			er := extract.AttachURL(c.readDetailsPage(bytes.NewReader(cand.body), &rec), cand.url)
+			extract.Track(c.Tracker, cand.url, er)
 			switch er {
 			case nil:
 				r = append(r, rec)
 			default:
-				err = errors.Join(err, fmt.Errorf("failed to extract record from %s: %w", candidateURL, er))
+				err = errors.Join(err, fmt.Errorf("failed to extract record from %s: %w", cand.url, er))
 			}
 
 		default:
-			maxN := len(u)
-			var recs = make([]storage.Record, maxN)
-			var flags = make(chan struct{}, maxN)
-			for i, url := range u {
-				go func() {
-					id := baseURL + url
-					var er error
-					if recs[i], er = c.Read(ctx, id); er != nil {
-						mu.Lock()
-						err = errors.Join(err, fmt.Errorf("failed to extract record from %s: %w", id, er))
-						mu.Unlock()
-					}
-					if err == nil && c.Dumper != nil {
-						_, _ = c.Dumper.Write(ctx, recs)
+			// the variants are the record's parts, hence the rest is not fetched once a variant failed
+			results := extract.Map(ctx, extract.Pool{Workers: c.Workers, Mode: extract.FailFast}, cand.variantURLs,
+				func(ctx context.Context, id string) (storage.Record, error) {
+					rec, er := c.Read(ctx, id)
+					extract.Track(c.Tracker, id, er)
+					if er != nil {
+						er = fmt.Errorf("failed to extract record from %s: %w", id, er)
 					}
-					flags <- struct{}{}
-				}()
-			}
-			for maxN > 0 {
-				<-flags
-				maxN--
-			}
-			if err == nil {
+					return rec, er
+				})
+			switch er := extract.JoinErrors(results); er == nil {
+			case true:
+				recs := extract.Values(results)
+				if err == nil && c.Dumper != nil {
+					_, _ = c.Dumper.Write(ctx, recs)
+				}
 				r = append(r, recs...)
+			case false:
+				err = errors.Join(err, er)
 			}
 		}
 	}
@@ -546,21 +647,16 @@ func (c Client) extractRecords(ctx context.Context, candidateURLPaths []string)
 	return r, err
 }
 
+var selectorVariantURL = htmlfilter.MustCompile("div.tab-pane ul.DetailVariant-list > li.DetailVariant " +
+	"a.DetailVariant-col.DetailVariant-data[href]")
+
 func extractURLs(v []byte) (urls []string, err error) {
 	var n *html.Node
 	if n, err = html.Parse(bytes.NewReader(v)); err == nil {
 		nn := htmlfilter.Node{Node: n}
-		for nnn := range nn.Find("div.tab-pane") {
-			for ul := range nnn.Find("ul.DetailVariant-list") {
-				for li := range ul.Find("li.DetailVariant") {
-					for a := range li.Find("a.DetailVariant-col.DetailVariant-data") {
-						for _, att := range a.Attr {
-							if att.Key == "href" {
-								urls = append(urls, att.Val)
-							}
-						}
-					}
-				}
+		for a := range selectorVariantURL.All(nn) {
+			if u, ok := a.Href(""); ok {
+				urls = append(urls, u)
 			}
 		}
 	}
@@ -578,30 +674,27 @@ func filterBulkURLs(v []string) []string {
 }
 
 func skipURL(s string) (skip bool) {
-	var filters = map[string]struct{}{"humidor": {}, "sample": {}, "jar": {}, "kiste": {}, "set": {}, "dose": {}}
-	for filter := range filters {
-		if skip = strings.Contains(s, filter); skip {
-			break
+	return notACigarKind(s) != ""
+}
+
+// notACigarKind returns the kind of the product found in the URL if the product is not a single cigar.
+func notACigarKind(s string) string {
+	for _, filter := range []string{"humidor", "sample", "jar", "kiste", "set", "dose"} {
+		if strings.Contains(s, filter) {
+			return filter
 		}
 	}
-	return skip
+	return ""
 }
 
 func newCandidateURLPaths(v io.Reader, filterFn func(s string) bool) (o []string, err error) {
 	var n *html.Node
 	if n, err = html.Parse(v); err == nil {
 		nn := htmlfilter.Node{Node: n}
-		for nn = range nn.Find("div.ws-g.search-result") {
-			for nnn := range nn.Find("div.search-result-item") {
-				for a := range nnn.Find("a.search-result-item-inner") {
-					var u string
-					for _, att := range a.Attr {
-						switch att.Key {
-						case "href":
-							u = att.Val
-						}
-					}
-
+		for nn = range selectorSearchResult.Find(nn) {
+			for nnn := range selectorSearchResultItem.Find(nn) {
+				for a := range selectorSearchResultLink.Find(nnn) {
+					u, _ := a.Href("")
 					if filterFn != nil && !filterFn(u) {
 						o = append(o, u)
 					}
@@ -620,25 +713,12 @@ func newPaginator(v io.Reader) (o map[string]string, err error) {
 	if n, err = html.Parse(v); err == nil {
 		nn := htmlfilter.Node{Node: n}
 
-		for nnn := range nn.Find("select#pagination_select") {
-			var urlQueryKey string
-			for _, att := range nnn.Attr {
-				if att.Key == "name" {
-					urlQueryKey = att.Val
-					break
-				}
-			}
-			if urlQueryKey != "" {
+		for nnn := range selectorPagination.Find(nn) {
+			if urlQueryKey, _ := nnn.Attr("name"); urlQueryKey != "" {
 				o = make(map[string]string)
-				for nnn := range nnn.Find("option") {
-					if nnn.LastChild != nil {
-						pageID := nnn.LastChild.Data
-						for _, att := range nnn.Attr {
-							if att.Key == "value" {
-								o[pageID] = fmt.Sprintf("%s=%s", urlQueryKey, att.Val)
-								break
-							}
-						}
+				for nnn := range selectorOption.Find(nnn) {
+					if v, ok := nnn.Attr("value"); ok && nnn.LastChild != nil {
+						o[nnn.LastChild.Data] = fmt.Sprintf("%s=%s", urlQueryKey, v)
 					}
 				}
 			}
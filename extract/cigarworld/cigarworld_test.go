@@ -11,6 +11,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/html"
@@ -72,27 +73,17 @@ var wantMyFatherCigarsLimitedEditionTatuajeLaUnion = storage.Record{
 	Price:                 87.3,
 	Details: map[string]string{
 		//nolint:misspell // Kollaboration is a correct German word
-		"description": "<p>Die exklusive<strong> Kollaboration</strong> zwischen " +
-			"<a href=\"/my-father-cigars\" target=\"_self\"><strong>My Father Cigars</strong>" +
-			"</a> und <a href=\"/tatuaje\" target=\"_self\"><strong>Tatuaje</strong>" +
-			"</a> hat den limitierten <strong>Sampler La Union</strong> für 2023 herausgebracht." +
-			"</p><div class=\"blanktag\" style=\"background: url('https://www.cigarworld.de/binary/shop/blank');\">" +
-			"</div>\n                                            " +
-			"<p>Dieses einzigartige Set enthält je 20 Zigarren der Sorten <strong>Prominente Especial Tatuaje" +
-			"</strong> mit einem Deckblatt aus nicaraguanischem Shade Grown Corojo 99 und <strong>" +
-			"Prominente Especial My Father</strong> mit einem Deckblatt aus Ecuador H-2000.</p>" +
-			"<div class=\"blanktag\" style=\"background: url('https://www.cigarworld.de/binary/shop/blank');\"></div>" +
-			"\n                                            " +
-			"<p><span>Wir freuen uns sehr, einige der <strong>weltweit nur 1.500" +
-			"</strong> produzierten schwarz-glänzenden Kisten ergattert zu haben, von denen lediglich " +
-			"30 ihren Weg nach Deutschland gefunden haben.</span></p><div class=\"blanktag\" style=\"background: " +
-			"url('https://www.cigarworld.de/binary/shop/blank');\"></div>" +
-			"\n                                            " +
-			"<p>Die Warnhinweise befinden sich NUR auf dem <strong>äußeren Karton</strong>, nicht wie bei unseren " +
-			"Bildern dargestellt, in oder auf der Kiste. D.h. die Kiste ist in ihrem <strong>schönen</strong>, " +
-			"<strong>unbeklebten</strong> Zustand. Wir haben diese Warnhinweise digital hinzugefügt, um " +
-			"gegebenenfalls Probleme mit Behörden zu vermeiden.</p><div class=\"blanktag\" style=\"background: " +
-			"url('https://www.cigarworld.de/binary/shop/blank');\"></div>",
+		"description": "Die exklusive **Kollaboration** zwischen [**My Father Cigars**](/my-father-cigars) und " +
+			"[**Tatuaje**](/tatuaje) hat den limitierten **Sampler La Union** für 2023 herausgebracht.\n\n" +
+			"Dieses einzigartige Set enthält je 20 Zigarren der Sorten **Prominente Especial Tatuaje** mit einem " +
+			"Deckblatt aus nicaraguanischem Shade Grown Corojo 99 und **Prominente Especial My Father** mit einem " +
+			"Deckblatt aus Ecuador H-2000.\n\n" +
+			"Wir freuen uns sehr, einige der **weltweit nur 1.500** produzierten schwarz-glänzenden Kisten " +
+			"ergattert zu haben, von denen lediglich 30 ihren Weg nach Deutschland gefunden haben.\n\n" +
+			"Die Warnhinweise befinden sich NUR auf dem **äußeren Karton**, nicht wie bei unseren Bildern " +
+			"dargestellt, in oder auf der Kiste. D.h. die Kiste ist in ihrem **schönen**, **unbeklebten** " +
+			"Zustand. Wir haben diese Warnhinweise digital hinzugefügt, um gegebenenfalls Probleme mit Behörden " +
+			"zu vermeiden.",
 	},
 }
 
@@ -129,6 +120,26 @@ func TestClient_Read(t *testing.T) {
 	}
 }
 
+func TestClient_Read_unparsableLength(t *testing.T) {
+	const id = "https://www.cigarworld.de/en/zigarren/nicaragua/diesel-cask-aged-robusto-90016191_48509"
+	page := bytes.Replace(detailsDieselCaskAgedRobusto, []byte("<u>5 inches</u>"), []byte("<u>n/a inches</u>"), 1)
+	tracker := extract.NewCheckpoint("cigarworld")
+	c := Client{HTTPClient: extract.MockHTTP{Body: io.NopCloser(bytes.NewReader(page))}, Tracker: tracker}
+
+	got, err := c.Read(context.TODO(), id)
+	assert.NoError(t, err)
+	want := wantDieselCaskAgedRobusto
+	want.LengthInch = 0
+	recordsEqual(t, want, got)
+
+	failures := tracker.FailuresSince(time.Time{})
+	if assert.Len(t, failures, 1) {
+		assert.True(t, failures[0].Warning)
+		assert.Equal(t, extract.FailureParse, failures[0].Kind)
+		assert.Equal(t, id, failures[0].URL)
+	}
+}
+
 func recordsEqual(t *testing.T, want storage.Record, got storage.Record) {
 	wantT := reflect.TypeOf(want)
 	wantV := reflect.ValueOf(want)
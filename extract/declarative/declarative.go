@@ -0,0 +1,414 @@
+package declarative
+
+import (
+	"cigarsdb/extract"
+	"cigarsdb/htmlfilter"
+	"cigarsdb/storage"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Register makes the source defined by the specification available by its name, see extract.Register.
+// Unlike extract.Register, it returns the error if the source with the same name was registered before,
+// because the specification is read from the user's input, unless the specification replaces it, see Spec.Replace.
+// The specification is compiled, see Spec.Compile.
+func Register(s Spec) error {
+	if err := s.Compile(); err != nil {
+		return err
+	}
+	if _, ok := extract.Lookup(s.Name); ok && !s.Replace {
+		return fmt.Errorf("source %q is registered already", s.Name)
+	}
+	extract.Replace(extract.Source{
+		Name:      s.Name,
+		BaseURL:   s.BaseURL,
+		Languages: s.Languages,
+		Fields:    s.Fields(),
+		Expected:  map[extract.PageType][]string{extract.PageDetail: s.Expected},
+		New: func(cfg extract.Config) storage.Reader {
+			return Client{Spec: s, HTTPClient: cfg.HTTPClient, Tracker: cfg.Tracker, Workers: cfg.Workers,
+				Provenance: cfg.Provenance, Metadata: cfg.Metadata}
+		},
+	})
+	return nil
+}
+
+// RegisterDir registers the specifications found in the directory, see LoadDir.
+// The replaced sources are logged as the warnings.
+func RegisterDir(dir string, logs *slog.Logger) error {
+	specs, err := LoadDir(dir)
+	for _, spec := range specs {
+		if err != nil {
+			break
+		}
+		if _, ok := extract.Lookup(spec.Name); ok && spec.Replace && logs != nil {
+			logs.Warn("the extractor spec replaces the source", slog.String("source", spec.Name))
+		}
+		err = Register(spec)
+	}
+	return err
+}
+
+// Fields returns the names of the storage.Record fields which the specification fills.
+func (s Spec) Fields() []string {
+	var o = []string{"URL"}
+	for _, f := range s.Detail.Fields {
+		if !slices.Contains(o, f.Field) {
+			o = append(o, f.Field)
+		}
+	}
+	return o
+}
+
+// Client defines the client to fetch data from the source defined by the compiled specification.
+type Client struct {
+	Spec       Spec
+	HTTPClient extract.HTTPClient
+	Tracker    extract.Tracker
+	// Workers the max number of the detail pages fetched concurrently.
+	Workers int
+	// Provenance completes the fields' provenance, it is dropped if nil.
+	Provenance *extract.Provenance
+	// Metadata stamps the records with the extraction metadata.
+	Metadata *extract.Metadata
+}
+
+func (c Client) Read(ctx context.Context, id string) (r storage.Record, err error) {
+	var resp *http.Response
+	if resp, err = extract.Get(ctx, c.HTTPClient, id); err == nil {
+		if err = extract.CheckStatus(resp, id); err == nil {
+			err = extract.AttachURL(c.Spec.readDetailsPage(resp.Body, &r), id)
+		}
+		_ = resp.Body.Close()
+		if err == nil {
+			r.URL = id
+		}
+	}
+	c.Provenance.Apply(&r)
+	c.Metadata.Apply(&r)
+	return r, err
+}
+
+func (c Client) ReadBulk(ctx context.Context, limit, page uint) (r []storage.Record, nextPage uint, err error) {
+	if page == 0 {
+		page = 1
+	}
+	limit = cmp.Or(c.Spec.List.PageSize, limit)
+	u := strings.NewReplacer(
+		"{page}", strconv.FormatUint(uint64(page), 10),
+		"{limit}", strconv.FormatUint(uint64(limit), 10),
+		"{offset}", strconv.FormatUint(uint64((page-1)*limit), 10),
+	).Replace(c.Spec.List.URL)
+
+	var (
+		urls    []string
+		hasNext bool
+		resp    *http.Response
+	)
+	if resp, err = extract.Get(ctx, c.HTTPClient, u); err == nil {
+		if err = extract.CheckStatus(resp, u); err == nil {
+			urls, hasNext, err = c.Spec.readListPage(resp.Body, u)
+		}
+		_ = resp.Body.Close()
+	}
+
+	if err == nil {
+		extract.Plan(c.Tracker, urls...)
+		results := extract.Map(ctx, extract.Pool{Workers: c.Workers}, urls,
+			func(ctx context.Context, u string) (storage.Record, error) {
+				rec, er := c.Read(ctx, u)
+				extract.Track(c.Tracker, u, er)
+				if er != nil {
+					er = fmt.Errorf("error reading details using %s: %w", u, er)
+				}
+				return rec, er
+			})
+		r = extract.Values(results)
+		err = extract.JoinErrors(results)
+		if hasNext {
+			nextPage = page + 1
+		}
+	}
+	return r, nextPage, err
+}
+
+func (s Spec) readListPage(v io.Reader, pageURL string) (urls []string, hasNext bool, err error) {
+	var doc *html.Node
+	if doc, err = html.Parse(v); err == nil {
+		n := htmlfilter.Node{Node: doc}
+		for a := range s.List.Items.all(n) {
+			u, ok := a.Href(pageURL)
+			if ok && !slices.Contains(urls, u) && (s.List.skip == nil || !s.List.skip.MatchString(u)) {
+				urls = append(urls, u)
+			}
+		}
+		switch s.List.Next {
+		case nil:
+			hasNext = len(urls) > 0
+		default:
+			_, hasNext = s.List.Next.first(n)
+		}
+	}
+	return urls, hasNext, err
+}
+
+func (s Spec) readDetailsPage(v io.Reader, o *storage.Record) error {
+	var err error
+	var doc *html.Node
+	if doc, err = html.Parse(v); err == nil {
+		n := htmlfilter.Node{Node: doc}
+		var rows map[string]htmlfilter.Node
+		if s.Detail.Rows != nil {
+			rows = s.Detail.Rows.read(n)
+		}
+		for _, f := range s.Detail.Fields {
+			err = errors.Join(err, f.read(n, rows, o))
+		}
+	}
+	return err
+}
+
+// read returns the rows' value nodes by the lower-cased labels, the first row wins if the label is repeated.
+func (r Rows) read(n htmlfilter.Node) map[string]htmlfilter.Node {
+	var o = make(map[string]htmlfilter.Node)
+	for row := range r.Row.all(n) {
+		label, ok := r.Label.first(row)
+		if !ok {
+			continue
+		}
+		k := strings.ToLower(label.Text())
+		if _, found := o[k]; found {
+			continue
+		}
+		if val, ok := r.Value.first(row); ok {
+			o[k] = val
+		}
+	}
+	return o
+}
+
+func (s Selector) String() string {
+	return cmp.Or(s.CSS, s.XPath)
+}
+
+func (s Selector) all(n htmlfilter.Node) iter.Seq[htmlfilter.Node] {
+	if s.XPath != "" {
+		return s.xpath.All(n)
+	}
+	return s.css.All(n)
+}
+
+func (s Selector) first(n htmlfilter.Node) (htmlfilter.Node, bool) {
+	if s.XPath != "" {
+		return s.xpath.First(n)
+	}
+	return s.css.First(n)
+}
+
+// read sets the field's value to the record, the missing value is not an error.
+func (f Field) read(n htmlfilter.Node, rows map[string]htmlfilter.Node, o *storage.Record) error {
+	var (
+		vals     []string
+		selector string
+		label    string
+	)
+	if len(f.Label) > 0 {
+		var found bool
+		for _, l := range f.Label {
+			if n, found = rows[strings.ToLower(l)]; found {
+				label = l
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+		selector = strings.Join(f.Label, "|")
+	}
+	switch f.Selector {
+	case nil:
+		vals = []string{f.content(n)}
+	default:
+		selector = strings.TrimPrefix(selector+" "+f.Selector.String(), " ")
+		switch {
+		case f.Selector.XPath != "" && f.Attr == "" && f.Content == "":
+			// the expression may select the attributes, or the text nodes
+			for _, v := range f.Selector.xpath.Values(n) {
+				vals = append(vals, strings.TrimSpace(v))
+			}
+		default:
+			for nn := range f.Selector.all(n) {
+				vals = append(vals, f.content(nn))
+			}
+		}
+	}
+
+	var raw = strings.Join(vals, "\n")
+	if vals = f.values(vals); len(vals) == 0 {
+		return nil
+	}
+	if err := setField(o, f, vals); err != nil {
+		return &extract.ParseError{Field: f.Field, Selector: selector, Value: raw, Err: err}
+	}
+	var p = storage.Provenance{Label: label, Raw: raw}
+	if f.Selector != nil {
+		p.Selector = f.Selector.String()
+	}
+	o.SetProvenance(f.Field, p)
+	return nil
+}
+
+func (f Field) content(n htmlfilter.Node) string {
+	var o string
+	switch {
+	case f.Attr != "":
+		o, _ = n.Attr(f.Attr)
+		o = strings.TrimSpace(o)
+	case f.Content == contentOwn:
+		o = n.OwnText()
+	case f.Content == contentMarkdown:
+		o = n.Markdown()
+	default:
+		o = n.Text()
+	}
+	return o
+}
+
+// values processes the raw values, the empty values are skipped.
+func (f Field) values(raw []string) []string {
+	var o = make([]string, 0, len(raw))
+	for _, v := range raw {
+		if f.pattern != nil {
+			switch m := f.pattern.FindStringSubmatch(v); {
+			case m == nil:
+				v = ""
+			case len(m) > 1:
+				v = m[1]
+			default:
+				v = m[0]
+			}
+		}
+		for _, t := range f.Transforms {
+			v = transforms[t](v)
+		}
+		var parts = []string{v}
+		if f.Split != "" {
+			parts = strings.Split(v, f.Split)
+		}
+		for _, p := range parts {
+			if p = strings.TrimSpace(p); p != "" {
+				o = append(o, f.mapValue(p))
+			}
+		}
+	}
+	return o
+}
+
+func (f Field) mapValue(v string) string {
+	for k, mapped := range f.Values {
+		if strings.EqualFold(k, v) {
+			return mapped
+		}
+	}
+	return v
+}
+
+func setField(o *storage.Record, f Field, vals []string) error {
+	var err error
+	v := reflect.ValueOf(o).Elem().FieldByName(f.Field)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(vals[0])
+
+	case reflect.Float64:
+		var x float64
+		if x, err = readNumber(vals[0], f.Unit, fieldUnits[f.Field]); err == nil {
+			v.SetFloat(x)
+		}
+
+	case reflect.Pointer:
+		switch v.Type().Elem().Kind() {
+		case reflect.String:
+			v.Set(reflect.ValueOf(&vals[0]))
+		case reflect.Bool:
+			var b bool
+			if b, err = strconv.ParseBool(vals[0]); err == nil {
+				v.Set(reflect.ValueOf(&b))
+			}
+		}
+
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(slices.Clone(vals)))
+
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(reflect.ValueOf(f.Key), reflect.ValueOf(strings.Join(vals, "\n")))
+	}
+	return err
+}
+
+const unitAuto = "auto"
+
+// unitsMM defines the length of the unit in mm.
+var unitsMM = map[string]float64{
+	"mm":   1,
+	"cm":   10,
+	"inch": 25.4,
+}
+
+// fieldUnits defines the units of the storage.Record length fields.
+var fieldUnits = map[string]string{
+	"Diameter":   "mm",
+	"Length":     "mm",
+	"LengthInch": "inch",
+}
+
+var (
+	numberPattern = regexp.MustCompile(`[0-9]+(?:[.,][0-9]+)?`)
+	unitPattern   = regexp.MustCompile(`(?i)[0-9]\s*(mm|cm|inches|inch|in|")`)
+)
+
+// readNumber reads the first number found in the value and converts it from the unit to the field's unit.
+// The result is rounded to two decimals to prevent the floating point noise, e.g., 15.6 cm -> 156 mm.
+func readNumber(v, unit, fieldUnit string) (float64, error) {
+	s := numberPattern.FindString(v)
+	if s == "" {
+		return 0, errors.New("number not found")
+	}
+	o, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if unit == unitAuto {
+		unit = ""
+		if m := unitPattern.FindStringSubmatch(v); m != nil {
+			switch u := strings.ToLower(m[1]); u {
+			case "mm", "cm":
+				unit = u
+			default:
+				unit = "inch"
+			}
+		}
+	}
+	if unit != "" && fieldUnit != "" && unit != fieldUnit {
+		o = math.Round(o*unitsMM[unit]/unitsMM[fieldUnit]*100) / 100
+	}
+	return o, nil
+}
@@ -0,0 +1,281 @@
+package declarative
+
+import (
+	"bytes"
+	"cigarsdb/extract"
+	"cigarsdb/storage"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pointer[V string | bool](v V) *V {
+	return &v
+}
+
+func TestClient_Read(t *testing.T) {
+	spec, err := Load("testdata/noblego.yaml")
+	assert.NoError(t, err)
+
+	page, err := os.ReadFile("../noblego/testdata/www.noblego.de-diesel-cask-aged-robusto-zigarren-152f095d.html")
+	assert.NoError(t, err)
+
+	const u = "https://www.noblego.de/diesel-cask-aged-robusto-zigarren/"
+	c := Client{Spec: spec, HTTPClient: extract.MockHTTP{Body: io.NopCloser(bytes.NewReader(page))}}
+	got, err := c.Read(context.TODO(), u)
+	assert.NoError(t, err)
+	assert.Equal(t, storage.Record{
+		Name:          "Diesel Cask Aged Robusto",
+		URL:           u,
+		Brand:         "Diesel",
+		Series:        "Cask Aged",
+		Diameter:      20.6,
+		Ring:          52,
+		Length:        127,
+		LengthInch:    5,
+		Format:        "Robusto",
+		Maker:         pointer("AJ Fernandez"),
+		IsBoxpressed:  pointer(false),
+		WrapperOrigin: []string{"USA"},
+		AromaProfileManufacturer: []string{
+			"Cremig", "Erdig", "Fruchtig", "Pfeffer", "Schokolade", "Trockenes Holz", "Zedernholz",
+		},
+		SmokingDuration: pointer("45 bis 60 Min"),
+		Price:           8.9,
+	}, got)
+}
+
+func TestClient_ReadBulk(t *testing.T) {
+	spec := Spec{
+		Name: "foo",
+		List: List{
+			URL:   "https://foo.com/list?p={page}&limit={limit}",
+			Items: Selector{CSS: "ul.items a"},
+			Next:  &Selector{XPath: "//a[@rel='next']"},
+			Skip:  "sampler",
+		},
+		Detail: Detail{
+			Fields: []Field{
+				{Field: "Name", Selector: &Selector{CSS: "h1"}},
+				{Field: "Length", Selector: &Selector{CSS: "span.length"}, Unit: unitAuto},
+				{Field: "Ring", Selector: &Selector{XPath: "//span[@class='ring']/@data-value"}},
+				{Field: "Details", Selector: &Selector{CSS: "div.description"}, Content: contentMarkdown,
+					Key: "description"},
+			},
+		},
+	}
+	assert.NoError(t, spec.Compile())
+
+	c := Client{Spec: spec, HTTPClient: extract.MockHTTP{BodyRoute: map[string]io.ReadCloser{
+		"https://foo.com/list?p=1&limit=10": io.NopCloser(bytes.NewReader([]byte(`<html><body>
+<ul class="items"><li><a href="/bar">Bar</a></li><li><a href="/bar-sampler">Sampler</a></li></ul>
+<a rel="next" href="?p=2">next</a></body></html>`))),
+		"https://foo.com/bar": io.NopCloser(bytes.NewReader([]byte(`<html><body><h1> Bar  No. 2 </h1>
+<span class="length">6 inches</span><span class="ring" data-value="52"></span>
+<div class="description"><p>Creamy <b>wood</b>.</p></div></body></html>`))),
+	}}}
+
+	got, nextPage, err := c.ReadBulk(context.TODO(), 10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), nextPage)
+	assert.Equal(t, []storage.Record{
+		{
+			Name:    "Bar No. 2",
+			URL:     "https://foo.com/bar",
+			Length:  152.4,
+			Ring:    52,
+			Details: map[string]string{"description": "Creamy **wood**."},
+		},
+	}, got)
+}
+
+func TestField_read(t *testing.T) {
+	t.Run("unmapped boolean value", func(t *testing.T) {
+		spec := Spec{Name: "foo", List: List{URL: "foo", Items: Selector{CSS: "a"}}, Detail: Detail{
+			Fields: []Field{{Field: "IsFlavoured", Selector: &Selector{CSS: "span"}, Values: map[string]string{"ja": "true"}}},
+		}}
+		assert.NoError(t, spec.Compile())
+
+		var got storage.Record
+		err := spec.readDetailsPage(bytes.NewReader([]byte(`<span>vielleicht</span>`)), &got)
+		var parseErr *extract.ParseError
+		assert.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, "IsFlavoured", parseErr.Field)
+		assert.Equal(t, "vielleicht", parseErr.Value)
+	})
+
+	t.Run("missing value is skipped", func(t *testing.T) {
+		spec := Spec{Name: "foo", List: List{URL: "foo", Items: Selector{CSS: "a"}}, Detail: Detail{
+			Fields: []Field{{Field: "Price", Selector: &Selector{CSS: "span.price"}}},
+		}}
+		assert.NoError(t, spec.Compile())
+
+		var got storage.Record
+		assert.NoError(t, spec.readDetailsPage(bytes.NewReader([]byte(`<span></span>`)), &got))
+		assert.Equal(t, storage.Record{}, got)
+	})
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0640))
+		return p
+	}
+
+	t.Run("json", func(t *testing.T) {
+		got, err := Load(write("foo.json", `{"name": "foo", "list": {"url": "https://foo.com?p={page}",
+"items": {"css": "a.item"}}, "detail": {"fields": [{"field": "Name", "selector": {"css": "h1"}}]}}`))
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", got.Name)
+		assert.Equal(t, []string{"URL", "Name"}, got.Fields())
+	})
+
+	t.Run("invalid spec", func(t *testing.T) {
+		_, err := Load(write("bar.yaml", `
+name: bar
+list:
+  url: https://bar.com
+  items:
+    css: "a["
+detail:
+  fields:
+    - field: Unknown
+      selector:
+        css: h1
+    - field: Details
+      label: [Foo]
+    - field: Length
+      selector:
+        xpath: //span
+      unit: furlong
+`))
+		assert.ErrorContains(t, err, "list.items")
+		assert.ErrorContains(t, err, "detail.fields.Unknown: unknown, or unsupported storage.Record field")
+		assert.ErrorContains(t, err, "detail.fields.Details: key must be provided")
+		assert.ErrorContains(t, err, "detail.fields.Details: label requires detail.rows")
+		assert.ErrorContains(t, err, `detail.fields.Length: unknown unit "furlong"`)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := Load(write("baz.toml", ``))
+		assert.ErrorContains(t, err, "unknown format")
+	})
+
+	t.Run("dir", func(t *testing.T) {
+		got, err := LoadDir("testdata")
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "noblego-declarative", got[0].Name)
+	})
+}
+
+func TestRegister(t *testing.T) {
+	spec, err := Load("testdata/noblego.yaml")
+	assert.NoError(t, err)
+	assert.NoError(t, Register(spec))
+	assert.Error(t, Register(spec))
+
+	got, ok := extract.Lookup(spec.Name)
+	assert.True(t, ok)
+	assert.Equal(t, "https://www.noblego.de", got.BaseURL)
+	assert.Contains(t, got.Fields, "Price")
+
+	t.Run("invalid spec", func(t *testing.T) {
+		spec := Spec{Name: "invalid", List: List{URL: "https://example.com", Items: Selector{CSS: "li["}}}
+		assert.Error(t, Register(spec))
+		_, ok := extract.Lookup(spec.Name)
+		assert.False(t, ok)
+	})
+}
+
+func TestRegisterDir(t *testing.T) {
+	spec, err := Load("testdata/noblego.yaml")
+	assert.NoError(t, err)
+	spec.Name = "noblego-replaced"
+	assert.NoError(t, Register(spec))
+
+	t.Run("replace", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "foo.yaml"), []byte(`name: noblego-replaced
+replace: true
+baseURL: https://foo.com
+list:
+  url: https://foo.com/list
+  items: {css: a}
+`), 0o640))
+		assert.NoError(t, RegisterDir(dir, nil))
+
+		got, ok := extract.Lookup(spec.Name)
+		assert.True(t, ok)
+		assert.Equal(t, "https://foo.com", got.BaseURL)
+	})
+
+	t.Run("registered source", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "foo.yaml"), []byte(`name: noblego-replaced
+baseURL: https://bar.com
+list:
+  url: https://bar.com/list
+  items: {css: a}
+`), 0o640))
+		assert.Error(t, RegisterDir(dir, nil))
+
+		got, _ := extract.Lookup(spec.Name)
+		assert.Equal(t, "https://foo.com", got.BaseURL)
+	})
+}
+
+func TestSpecs(t *testing.T) {
+	t.Run("cigarworld", func(t *testing.T) {
+		spec, err := Load("../../specs/cigarworld.yaml")
+		assert.NoError(t, err)
+		assert.NoError(t, spec.Compile())
+		assert.True(t, spec.Replace)
+
+		page, err := os.ReadFile("../cigarworld/testdata/details-diesel-cask-aged-robusto-90016191_48509.html")
+		assert.NoError(t, err)
+
+		const u = "https://www.cigarworld.de/zigarren/nicaragua/diesel-cask-aged-robusto-90016191_48509"
+		c := Client{Spec: spec, HTTPClient: extract.MockHTTP{Body: io.NopCloser(bytes.NewReader(page))}}
+		got, err := c.Read(context.TODO(), u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Diesel Cask Aged Robusto", got.Name)
+		assert.Equal(t, "Diesel", got.Brand)
+		assert.Equal(t, "Robusto", got.Series)
+		assert.Equal(t, "Robustos", got.Format)
+		assert.Equal(t, pointer("A.J. Fernandez"), got.Maker)
+		assert.Equal(t, pointer("TAM"), got.TypeOfManufacturing)
+		assert.Equal(t, pointer(false), got.IsBoxpressed)
+		assert.Equal(t, pointer(false), got.IsFlavoured)
+		assert.Equal(t, 127.0, got.Length)
+		assert.Equal(t, 5.0, got.LengthInch)
+		assert.Equal(t, 52.0, got.Ring)
+		assert.Equal(t, 20.6, got.Diameter)
+		assert.Equal(t, 8.9, got.Price)
+	})
+
+	t.Run("cigarworld list", func(t *testing.T) {
+		spec, err := Load("../../specs/cigarworld.yaml")
+		assert.NoError(t, err)
+		assert.NoError(t, spec.Compile())
+
+		page, err := os.ReadFile("../cigarworld/testdata/list.html")
+		assert.NoError(t, err)
+
+		c := Client{Spec: spec, HTTPClient: extract.MockHTTP{
+			Body: io.NopCloser(bytes.NewReader(nil)),
+			BodyRoute: map[string]io.ReadCloser{
+				"https://www.cigarworld.de/zigarren?von=30": io.NopCloser(bytes.NewReader(page)),
+			},
+		}}
+		_, nextPage, _ := c.ReadBulk(context.TODO(), 10, 2)
+		assert.Equal(t, uint(3), nextPage)
+	})
+}
@@ -0,0 +1,300 @@
+// Package declarative defines the generic extractor interpreting the source's specification loaded from YAML, or JSON.
+// The specification lists the selectors of the list and the detail pages together with the mapping of the selected
+// values to the storage.Record fields, hence a new source, or a selector change is shipped without recompiling.
+package declarative
+
+import (
+	"cigarsdb/htmlfilter"
+	"cigarsdb/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec defines the extractor of the source.
+type Spec struct {
+	// Name unique identifier of the source, e.g., noblego.
+	Name string `json:"name" yaml:"name"`
+	// Replace the registered source with the same name, e.g., to ship the selector change of the built-in extractor.
+	Replace bool `json:"replace,omitempty" yaml:"replace,omitempty"`
+	// BaseURL the root URL of the source website.
+	BaseURL string `json:"baseURL" yaml:"baseURL"`
+	// Languages ISO 639-1 codes of the languages used on the website.
+	Languages []string `json:"languages,omitempty" yaml:"languages,omitempty"`
+	// Expected the names of the fields expected to be filled on every detail page, see extract.HealthCheck.
+	Expected []string `json:"expected,omitempty" yaml:"expected,omitempty"`
+	List     List     `json:"list" yaml:"list"`
+	Detail   Detail   `json:"detail" yaml:"detail"`
+}
+
+// List defines the page listing the URLs of the detail pages.
+type List struct {
+	// URL the list page's URL template, the placeholders {page} and {limit} are replaced with the requested values,
+	// {offset} is replaced with the number of the items listed on the previous pages.
+	URL string `json:"url" yaml:"url"`
+	// PageSize the number of the items listed on the page if the website does not accept the requested limit.
+	PageSize uint `json:"pageSize,omitempty" yaml:"pageSize,omitempty"`
+	// Items selects the links to the detail pages, their href is resolved against the list page's URL.
+	Items Selector `json:"items" yaml:"items"`
+	// Next selects the link to the next page. The next page exists if the node is found.
+	// The next page is requested until the list page has no items if the selector is not set.
+	Next *Selector `json:"next,omitempty" yaml:"next,omitempty"`
+	// Skip the items which URL matches the regular expression, e.g., samplers.
+	Skip string `json:"skip,omitempty" yaml:"skip,omitempty"`
+
+	skip *regexp.Regexp
+}
+
+// Detail defines the page describing a single cigar.
+type Detail struct {
+	// Rows selects the label-value pairs of the attributes table, see Field.Label.
+	Rows   *Rows   `json:"rows,omitempty" yaml:"rows,omitempty"`
+	Fields []Field `json:"fields" yaml:"fields"`
+}
+
+// Rows defines the attributes table, e.g., the list of the dt-dd pairs.
+type Rows struct {
+	// Row selects the rows of the table.
+	Row Selector `json:"row" yaml:"row"`
+	// Label selects the node with the attribute's name within the row.
+	Label Selector `json:"label" yaml:"label"`
+	// Value selects the node with the attribute's value within the row.
+	Value Selector `json:"value" yaml:"value"`
+}
+
+// Selector defines the node search using either the CSS selector, or the XPath expression.
+type Selector struct {
+	CSS   string `json:"css,omitempty" yaml:"css,omitempty"`
+	XPath string `json:"xpath,omitempty" yaml:"xpath,omitempty"`
+
+	css   htmlfilter.Selector
+	xpath htmlfilter.XPath
+}
+
+// Field defines the mapping of the page's value to the storage.Record field.
+//
+// The raw value is processed in the following order:
+//   - the text, the own text, the Markdown, or the attribute of the selected node is taken, see Content and Attr;
+//   - the Pattern is applied;
+//   - the Transforms are applied;
+//   - the value is split to the list by Split;
+//   - the value is mapped using Values;
+//   - the value is converted to the field's type, the numeric value is converted from Unit to the field's unit.
+type Field struct {
+	// Field the name of the storage.Record field, e.g., Brand.
+	Field string `json:"field" yaml:"field"`
+	// Label selects the value by the attribute's name found in the Detail.Rows, either of the labels matches.
+	Label []string `json:"label,omitempty" yaml:"label,omitempty"`
+	// Selector selects the value node, it is searched within the row's value node if Label is set.
+	Selector *Selector `json:"selector,omitempty" yaml:"selector,omitempty"`
+	// Attr the attribute holding the value, the node's content is taken if it is not set.
+	Attr string `json:"attr,omitempty" yaml:"attr,omitempty"`
+	// Content defines how the node's content is read: text (default), own, or markdown.
+	Content string `json:"content,omitempty" yaml:"content,omitempty"`
+	// Pattern the regular expression to extract the value, the first capturing group is taken if defined.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// Transforms the names of the string transformations, see transforms.
+	Transforms []string `json:"transforms,omitempty" yaml:"transforms,omitempty"`
+	// Split the separator of the list values.
+	Split string `json:"split,omitempty" yaml:"split,omitempty"`
+	// Values maps the raw values, e.g., {"ja": "true"} for the boolean field. The value is matched ignoring the case.
+	Values map[string]string `json:"values,omitempty" yaml:"values,omitempty"`
+	// Unit the unit of the numeric value: mm, cm, inch, or auto to read it from the value's suffix.
+	Unit string `json:"unit,omitempty" yaml:"unit,omitempty"`
+	// Key the key of the value in the map field, e.g., description for Details.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Load reads the specification from the file, the format is defined by the file's extension: .json, .yaml, or .yml.
+func Load(path string) (Spec, error) {
+	var (
+		o   Spec
+		b   []byte
+		err error
+	)
+	if b, err = os.ReadFile(path); err == nil {
+		switch ext := strings.ToLower(filepath.Ext(path)); ext {
+		case ".json":
+			err = json.Unmarshal(b, &o)
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(b, &o)
+		default:
+			err = fmt.Errorf("unknown format %q", ext)
+		}
+	}
+	if err == nil {
+		err = o.Compile()
+	}
+	if err != nil {
+		err = fmt.Errorf("could not load the extractor spec %s: %w", path, err)
+	}
+	return o, err
+}
+
+// LoadDir reads the specifications from all files of the directory with the extensions supported by Load.
+func LoadDir(dir string) ([]Spec, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var o []Spec
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".json", ".yaml", ".yml":
+			var s Spec
+			if s, err = Load(filepath.Join(dir, e.Name())); err != nil {
+				return nil, err
+			}
+			o = append(o, s)
+		}
+	}
+	return o, nil
+}
+
+// Compile validates the specification and compiles its selectors and patterns.
+func (s *Spec) Compile() error {
+	var err error
+	if s.Name == "" {
+		err = errors.Join(err, errors.New("name must be provided"))
+	}
+	if s.List.URL == "" {
+		err = errors.Join(err, errors.New("list url must be provided"))
+	}
+	err = errors.Join(err, s.List.Items.compile("list.items"))
+	if s.List.Next != nil {
+		err = errors.Join(err, s.List.Next.compile("list.next"))
+	}
+	if s.List.Skip != "" {
+		var er error
+		if s.List.skip, er = regexp.Compile(s.List.Skip); er != nil {
+			err = errors.Join(err, fmt.Errorf("list.skip: %w", er))
+		}
+	}
+	if r := s.Detail.Rows; r != nil {
+		err = errors.Join(err, r.Row.compile("detail.rows.row"), r.Label.compile("detail.rows.label"),
+			r.Value.compile("detail.rows.value"))
+	}
+	for _, f := range s.Expected {
+		if _, ok := fieldKinds[f]; !ok {
+			err = errors.Join(err, fmt.Errorf("expected: unknown, or unsupported storage.Record field %s", f))
+		}
+	}
+	for i := range s.Detail.Fields {
+		err = errors.Join(err, s.Detail.Fields[i].compile(s.Detail.Rows != nil))
+	}
+	return err
+}
+
+func (s *Selector) compile(path string) error {
+	var err error
+	switch {
+	case s.CSS != "" && s.XPath != "":
+		err = errors.New("either css, or xpath must be set")
+	case s.CSS != "":
+		s.css, err = htmlfilter.Compile(s.CSS)
+	case s.XPath != "":
+		s.xpath, err = htmlfilter.CompileXPath(s.XPath)
+	default:
+		err = errors.New("css, or xpath must be set")
+	}
+	if err != nil {
+		err = fmt.Errorf("%s: %w", path, err)
+	}
+	return err
+}
+
+func (f *Field) compile(hasRows bool) error {
+	path := "detail.fields." + f.Field
+	var err error
+	switch kind, ok := fieldKinds[f.Field]; {
+	case !ok:
+		err = errors.Join(err, fmt.Errorf("%s: unknown, or unsupported storage.Record field", path))
+	case kind == reflect.Map && f.Key == "":
+		err = errors.Join(err, fmt.Errorf("%s: key must be provided", path))
+	}
+	switch {
+	case len(f.Label) > 0 && !hasRows:
+		err = errors.Join(err, fmt.Errorf("%s: label requires detail.rows", path))
+	case len(f.Label) == 0 && f.Selector == nil:
+		err = errors.Join(err, fmt.Errorf("%s: label, or selector must be provided", path))
+	}
+	if f.Selector != nil {
+		err = errors.Join(err, f.Selector.compile(path+".selector"))
+	}
+	if !slices.Contains([]string{"", contentText, contentOwn, contentMarkdown}, f.Content) {
+		err = errors.Join(err, fmt.Errorf("%s: unknown content %q", path, f.Content))
+	}
+	if f.Pattern != "" {
+		var er error
+		if f.pattern, er = regexp.Compile(f.Pattern); er != nil {
+			err = errors.Join(err, fmt.Errorf("%s.pattern: %w", path, er))
+		}
+	}
+	for _, t := range f.Transforms {
+		if _, ok := transforms[t]; !ok {
+			err = errors.Join(err, fmt.Errorf("%s: unknown transform %q", path, t))
+		}
+	}
+	if _, ok := unitsMM[f.Unit]; !ok && f.Unit != "" && f.Unit != unitAuto {
+		err = errors.Join(err, fmt.Errorf("%s: unknown unit %q", path, f.Unit))
+	}
+	return err
+}
+
+const (
+	contentText     = "text"
+	contentOwn      = "own"
+	contentMarkdown = "markdown"
+)
+
+// transforms defines the string transformations applied to the raw value.
+var transforms = map[string]func(s string) string{
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	// decimal-comma replaces the decimal comma used by the German websites, e.g., 15,6 -> 15.6.
+	"decimal-comma": func(s string) string {
+		return strings.ReplaceAll(s, ",", ".")
+	},
+	// collapse-space collapses the whitespaces, including the non-breaking spaces.
+	"collapse-space": func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	},
+}
+
+// fieldKinds defines the storage.Record fields which can be set by the specification.
+var fieldKinds = func() map[string]reflect.Kind {
+	var o = make(map[string]reflect.Kind)
+	t := reflect.TypeOf(storage.Record{})
+	for i := range t.NumField() {
+		f := t.Field(i)
+		switch k := f.Type.Kind(); k {
+		case reflect.String, reflect.Float64:
+			o[f.Name] = k
+		case reflect.Pointer:
+			switch f.Type.Elem().Kind() {
+			case reflect.String, reflect.Bool:
+				o[f.Name] = k
+			}
+		case reflect.Slice:
+			if f.Type.Elem().Kind() == reflect.String {
+				o[f.Name] = k
+			}
+		case reflect.Map:
+			if f.Type.Key().Kind() == reflect.String && f.Type.Elem().Kind() == reflect.String {
+				o[f.Name] = k
+			}
+		}
+	}
+	return o
+}()
@@ -0,0 +1,180 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// HTTPStatusError defines the error returned when the server responds with the unsuccessful status.
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s from %s", e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// CheckStatus returns the HTTPStatusError if the response's status is not 2xx.
+// The status 401, 403 and 451 yield the BlockedError which wraps the HTTPStatusError.
+func CheckStatus(resp *http.Response, url string) error {
+	var err error
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+	case code == http.StatusUnauthorized || code == http.StatusForbidden || code == http.StatusUnavailableForLegalReasons:
+		err = &BlockedError{
+			URL:    url,
+			Reason: "access denied",
+			Err:    &HTTPStatusError{URL: url, StatusCode: code},
+		}
+	default:
+		err = &HTTPStatusError{URL: url, StatusCode: code}
+	}
+	return err
+}
+
+// ParseError defines the error returned when the record's attribute could not be extracted from the page.
+type ParseError struct {
+	// URL the page's URL, it is empty if the error was returned by the function which reads the page's content.
+	URL string
+	// Field the name of the storage.Record field.
+	Field string
+	// Selector the html selector of the attribute's node.
+	Selector string
+	// Value the raw value of the attribute.
+	Value string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	s := fmt.Sprintf("could not parse the field %s", e.Field)
+	if e.Selector != "" {
+		s += fmt.Sprintf(" selected by %q", e.Selector)
+	}
+	if e.Value != "" {
+		s += fmt.Sprintf(" from the value %q", e.Value)
+	}
+	if e.URL != "" {
+		s += " of " + e.URL
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// AttachURL sets the URL to the ParseError found in the error's tree if the URL was not set before.
+func AttachURL(err error, url string) error {
+	switch v := err.(type) {
+	case *ParseError:
+		if v.URL == "" {
+			v.URL = url
+		}
+	case interface{ Unwrap() []error }:
+		for _, e := range v.Unwrap() {
+			AttachURL(e, url)
+		}
+	case interface{ Unwrap() error }:
+		AttachURL(v.Unwrap(), url)
+	}
+	return err
+}
+
+// BlockedError defines the error returned when the request was refused, e.g., by robots.txt, or by the server.
+type BlockedError struct {
+	URL    string
+	Reason string
+	Err    error
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("request to %s is blocked: %s", e.URL, e.Reason)
+}
+
+func (e *BlockedError) Unwrap() error {
+	return e.Err
+}
+
+// Warning defines the error which is reported, but does not fail the record,
+// e.g., the optional attribute which could not be parsed.
+type Warning struct {
+	Err error
+}
+
+func (e *Warning) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Warning) Unwrap() error {
+	return e.Err
+}
+
+// SplitWarnings separates the warnings from the errors which fail the record in the tree joined by errors.Join.
+func SplitWarnings(err error) (warnings []error, rest error) {
+	switch v := err.(type) {
+	case nil:
+	case *Warning:
+		warnings = append(warnings, v)
+	case interface{ Unwrap() []error }:
+		var errs []error
+		for _, e := range v.Unwrap() {
+			w, er := SplitWarnings(e)
+			warnings = append(warnings, w...)
+			errs = append(errs, er)
+		}
+		rest = errors.Join(errs...)
+	default:
+		rest = err
+	}
+	return warnings, rest
+}
+
+// NotACigarError defines the error returned when the page does not describe a single cigar, e.g., a sampler.
+type NotACigarError struct {
+	URL string
+	// Kind the kind of the product, e.g., sampler, or humidor.
+	Kind string
+}
+
+func (e *NotACigarError) Error() string {
+	return fmt.Sprintf("%s is not a cigar, but a %s", e.URL, e.Kind)
+}
+
+// FailureKind defines the category of the extraction failure.
+type FailureKind string
+
+const (
+	FailureHTTPStatus FailureKind = "http_status"
+	FailureParse      FailureKind = "parse"
+	FailureBlocked    FailureKind = "blocked"
+	FailureNotACigar  FailureKind = "not_a_cigar"
+	FailureOther      FailureKind = "other"
+)
+
+// ClassifyError defines the category of the error.
+// The categories are checked in the order of their specificity: not a cigar, blocked, http status, parse.
+func ClassifyError(err error) FailureKind {
+	var (
+		notACigar  *NotACigarError
+		blocked    *BlockedError
+		httpStatus *HTTPStatusError
+		parse      *ParseError
+	)
+	var o = FailureOther
+	switch {
+	case errors.As(err, &notACigar):
+		o = FailureNotACigar
+	case errors.As(err, &blocked):
+		o = FailureBlocked
+	case errors.As(err, &httpStatus):
+		o = FailureHTTPStatus
+	case errors.As(err, &parse):
+		o = FailureParse
+	}
+	return o
+}
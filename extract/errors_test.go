@@ -0,0 +1,94 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStatus(t *testing.T) {
+	const u = "https://foo.bar/baz"
+	tests := map[string]struct {
+		statusCode int
+		wantKind   FailureKind
+		wantNil    bool
+	}{
+		"ok":           {statusCode: http.StatusOK, wantNil: true},
+		"not found":    {statusCode: http.StatusNotFound, wantKind: FailureHTTPStatus},
+		"server error": {statusCode: http.StatusBadGateway, wantKind: FailureHTTPStatus},
+		"forbidden":    {statusCode: http.StatusForbidden, wantKind: FailureBlocked},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := CheckStatus(&http.Response{StatusCode: tt.statusCode}, u)
+			if tt.wantNil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.wantKind, ClassifyError(err))
+			var e *HTTPStatusError
+			if assert.ErrorAs(t, err, &e) {
+				assert.Equal(t, tt.statusCode, e.StatusCode)
+				assert.Equal(t, u, e.URL)
+			}
+		})
+	}
+}
+
+func TestAttachURL(t *testing.T) {
+	pe := &ParseError{Field: "Ring", Selector: "li.ring", Value: "foo", Err: errors.New("invalid syntax")}
+	err := AttachURL(errors.Join(errors.New("bar"), fmt.Errorf("baz: %w", pe)), "https://foo.bar/baz")
+
+	var got *ParseError
+	if assert.ErrorAs(t, err, &got) {
+		assert.Equal(t, "https://foo.bar/baz", got.URL)
+		assert.Equal(t, `could not parse the field Ring selected by "li.ring" from the value "foo" `+
+			`of https://foo.bar/baz: invalid syntax`, got.Error())
+	}
+	assert.NoError(t, AttachURL(nil, "https://foo.bar/baz"))
+}
+
+func TestSplitWarnings(t *testing.T) {
+	fatal := errors.New("bar")
+	w := &Warning{Err: &ParseError{Field: "Length"}}
+	warnings, err := SplitWarnings(errors.Join(errors.Join(nil, w), fatal))
+	assert.Equal(t, []error{w}, warnings)
+	assert.ErrorIs(t, err, fatal)
+	assert.NotErrorIs(t, err, w)
+
+	warnings, err = SplitWarnings(errors.Join(w))
+	assert.Equal(t, []error{w}, warnings)
+	assert.NoError(t, err)
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want FailureKind
+	}{
+		"robots.txt": {
+			err:  fmt.Errorf("foo: %w", &DisallowedError{URL: "https://foo.bar", UserAgent: "qux"}),
+			want: FailureBlocked,
+		},
+		"not a cigar": {
+			err:  errors.Join(&ParseError{Field: "Ring"}, &NotACigarError{URL: "https://foo.bar", Kind: "humidor"}),
+			want: FailureNotACigar,
+		},
+		"parse": {
+			err:  &ParseError{Field: "Ring"},
+			want: FailureParse,
+		},
+		"other": {
+			err:  errors.New("foo"),
+			want: FailureOther,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyError(tt.err))
+		})
+	}
+}
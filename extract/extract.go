@@ -41,6 +41,9 @@ func (m MockHTTP) Do(req *http.Request) (*http.Response, error) {
 			StatusCode: http.StatusOK,
 			Body:       m.Body,
 		}
+		if v, ok := m.BodyRoute[req.URL.String()]; ok {
+			r.Body = v
+		}
 		if v, ok := m.BodyReq[req]; ok {
 			r.Body = v
 		}
@@ -48,17 +51,34 @@ func (m MockHTTP) Do(req *http.Request) (*http.Response, error) {
 	return r, m.Err
 }
 
+// Get sends the GET request which is cancelled when the context is done.
+func Get(ctx context.Context, c interface {
+	Do(req *http.Request) (*http.Response, error)
+}, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	return c.Do(req)
+}
+
+// ProcessReq sends the GET request and processes the successful response's body using fn.
+// The unsuccessful response yields the HTTPStatusError, or the BlockedError.
 func ProcessReq(ctx context.Context, c HTTPClient, url string, headers http.Header,
 	fn func(ctx context.Context, v io.ReadCloser) (any, error)) (o any, err error) {
 	var req *http.Request
-	req, err = http.NewRequest(http.MethodGet, url, nil)
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		err = fmt.Errorf("could not create request: %w", err)
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	if headers != nil {
+		req.Header = headers
 	}
-	req.Header = headers
 
 	var resp *http.Response
-	resp, err = c.Do(req)
+	if resp, err = c.Do(req); err == nil {
+		err = CheckStatus(resp, url)
+	}
 
 	switch err == nil {
 	case true:
@@ -70,6 +90,7 @@ func ProcessReq(ctx context.Context, c HTTPClient, url string, headers http.Head
 		var er error
 		if resp != nil {
 			respBytes, er = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
 			switch er == nil {
 			case true:
 				err = fmt.Errorf("error reading %s, body: %s, error: %w", url, respBytes, err)
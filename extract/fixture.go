@@ -0,0 +1,245 @@
+package extract
+
+import (
+	"bytes"
+	"cigarsdb/storage"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FixtureManifestFile defines the name of the file which describes the recorded run.
+const FixtureManifestFile = "manifest.json"
+
+// ErrNotRecorded defines the error returned by the ReplayClient when the request was not recorded.
+var ErrNotRecorded = errors.New("response is not recorded")
+
+// FixtureManifest defines the recorded run of the source's extraction.
+type FixtureManifest struct {
+	// Source the name of the data source.
+	Source string `json:"source,omitempty"`
+	// Entries the recorded responses by the request URL.
+	Entries map[string]FixtureEntry `json:"entries"`
+	// Pages the results of the source's ReadBulk calls made during the run.
+	Pages []FixturePage `json:"pages,omitempty"`
+}
+
+// FixtureEntry defines the recorded response.
+type FixtureEntry struct {
+	Method     string      `json:"method,omitempty"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	// File the path to the response body relative to the fixture directory.
+	File string `json:"file"`
+}
+
+// FixturePage defines the result of the ReadBulk call, i.e., the expected output when the run is replayed.
+type FixturePage struct {
+	Page     uint             `json:"page"`
+	Limit    uint             `json:"limit"`
+	NextPage uint             `json:"nextPage"`
+	Records  []storage.Record `json:"records"`
+}
+
+// RecordingClient wraps the HTTPClient to store every response of the run as the test fixture.
+// The bodies are written to the fixture directory as received, the manifest maps the URLs to the files.
+// The latest response is kept if the URL was requested several times.
+type RecordingClient struct {
+	HTTPClient HTTPClient
+	// Dir the fixture directory.
+	Dir string
+
+	mu       *sync.Mutex
+	manifest FixtureManifest
+}
+
+// NewRecordingClient initialises the client which records the responses of the source's run to the directory.
+func NewRecordingClient(c HTTPClient, dir, source string) (*RecordingClient, error) {
+	var o *RecordingClient
+	err := os.MkdirAll(dir, 0750)
+	if err == nil {
+		o = &RecordingClient{
+			HTTPClient: c,
+			Dir:        dir,
+			mu:         new(sync.Mutex),
+			manifest:   FixtureManifest{Source: source, Entries: make(map[string]FixtureEntry)},
+		}
+	}
+	return o, err
+}
+
+func (c *RecordingClient) Get(url string) (*http.Response, error) {
+	resp, err := c.HTTPClient.Get(url)
+	if err == nil {
+		err = c.record(http.MethodGet, url, resp)
+	}
+	return resp, err
+}
+
+func (c *RecordingClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err == nil {
+		err = c.record(req.Method, req.URL.String(), resp)
+	}
+	return resp, err
+}
+
+func (c *RecordingClient) record(method, u string, resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not read the response from %s: %w", u, err)
+	}
+
+	entry := FixtureEntry{
+		Method:     method,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		File:       fixtureFileName(u, resp.Header.Get("Content-Type")),
+	}
+	if err = writeFileAtomic(filepath.Join(c.Dir, entry.File), body); err != nil {
+		return fmt.Errorf("could not record the response from %s: %w", u, err)
+	}
+
+	c.mu.Lock()
+	c.manifest.Entries[u] = entry
+	c.mu.Unlock()
+	return nil
+}
+
+// RecordPage stores the result of the ReadBulk call to be compared against when the run is replayed.
+// The records' metadata is not stored because the fetch time differs between the runs.
+func (c *RecordingClient) RecordPage(page, limit, nextPage uint, records []storage.Record) {
+	records = slices.Clone(records)
+	for i := range records {
+		records[i].Metadata = nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.manifest.Pages = append(c.manifest.Pages, FixturePage{
+		Page: page, Limit: limit, NextPage: nextPage, Records: records,
+	})
+}
+
+// Save writes the manifest to the fixture directory.
+func (c *RecordingClient) Save() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	c.mu.Lock()
+	err := enc.Encode(c.manifest)
+	c.mu.Unlock()
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(c.Dir, FixtureManifestFile), buf.Bytes())
+	}
+	return err
+}
+
+// fixtureFileName generates the readable file name which is unique for the URL.
+func fixtureFileName(u, contentType string) string {
+	var name string
+	if v, err := url.Parse(u); err == nil {
+		name = v.Host + v.Path
+	}
+	name = strings.Trim(strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '.' {
+			return r
+		}
+		return '-'
+	}, name), "-.")
+	const maxLen = 64
+	if len(name) > maxLen {
+		name = name[:maxLen]
+	}
+
+	sum := sha256.Sum256([]byte(u))
+	name += "-" + hex.EncodeToString(sum[:4])
+
+	var ext string
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case strings.HasSuffix(mediaType, "html"):
+			ext = ".html"
+		case strings.HasSuffix(mediaType, "json"):
+			ext = ".json"
+		case strings.HasSuffix(mediaType, "xml"):
+			ext = ".xml"
+		case strings.HasPrefix(mediaType, "text/"):
+			ext = ".txt"
+		}
+	}
+	return name + ext
+}
+
+// ReplayClient serves the responses recorded by the RecordingClient.
+// The requests which were not recorded fail with ErrNotRecorded.
+type ReplayClient struct {
+	Manifest FixtureManifest
+
+	fsys fs.FS
+}
+
+// NewReplayClient initialises the client which serves the responses from the fixture.
+// The fixture's manifest is expected in the root of the file system.
+func NewReplayClient(fsys fs.FS) (*ReplayClient, error) {
+	var o *ReplayClient
+	b, err := fs.ReadFile(fsys, FixtureManifestFile)
+	if err == nil {
+		var m FixtureManifest
+		if err = json.Unmarshal(b, &m); err == nil {
+			o = &ReplayClient{Manifest: m, fsys: fsys}
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("could not read the fixture's manifest: %w", err)
+	}
+	return o, err
+}
+
+// LoadFixture initialises the client which serves the responses from the fixture directory.
+func LoadFixture(dir string) (*ReplayClient, error) {
+	return NewReplayClient(os.DirFS(dir))
+}
+
+func (c *ReplayClient) Get(url string) (*http.Response, error) {
+	return c.serve(http.MethodGet, url, nil)
+}
+
+func (c *ReplayClient) Do(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	return c.serve(req.Method, req.URL.String(), req)
+}
+
+func (c *ReplayClient) serve(method, u string, req *http.Request) (*http.Response, error) {
+	if method == "" {
+		method = http.MethodGet
+	}
+	entry, ok := c.Manifest.Entries[u]
+	if !ok || (entry.Method != "" && entry.Method != method) {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, method, u)
+	}
+
+	body, err := fs.ReadFile(c.fsys, path.Clean(filepath.ToSlash(entry.File)))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the recorded response from %s: %w", u, err)
+	}
+	return CacheEntry{URL: u, StatusCode: entry.StatusCode, Header: entry.Header}.response(req, body), nil
+}
@@ -0,0 +1,110 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordingClient_ReplayClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte("<html>" + r.URL.Path + "</html>"))
+		}
+	}))
+	defer srv.Close()
+
+	readBody := func(t *testing.T, resp *http.Response) string {
+		t.Helper()
+		b, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		return string(b)
+	}
+
+	dir := t.TempDir()
+	rec, err := NewRecordingClient(http.DefaultClient, dir, "foo")
+	assert.NoError(t, err)
+
+	resp, err := rec.Get(srv.URL + "/foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "<html>/foo</html>", readBody(t, resp))
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/missing", nil)
+	resp, err = rec.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	rec.RecordPage(1, 10, 0, []storage.Record{{Name: "bar", URL: srv.URL + "/foo"}})
+	assert.NoError(t, rec.Save())
+
+	c, err := LoadFixture(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", c.Manifest.Source)
+	assert.Equal(t, []FixturePage{
+		{Page: 1, Limit: 10, Records: []storage.Record{{Name: "bar", URL: srv.URL + "/foo"}}},
+	}, c.Manifest.Pages)
+
+	t.Run("recorded response is served repeatedly", func(t *testing.T) {
+		for range 2 {
+			resp, err := c.Get(srv.URL + "/foo")
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+			assert.Equal(t, "<html>/foo</html>", readBody(t, resp))
+		}
+	})
+
+	t.Run("status code is replayed", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/missing", nil)
+		resp, err := c.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("unknown request", func(t *testing.T) {
+		_, err := c.Get(srv.URL + "/bar")
+		assert.ErrorIs(t, err, ErrNotRecorded)
+
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/foo", nil)
+		_, err = c.Do(req)
+		assert.ErrorIs(t, err, ErrNotRecorded)
+	})
+}
+
+func Test_fixtureFileName(t *testing.T) {
+	tests := map[string]struct {
+		url         string
+		contentType string
+		want        string
+	}{
+		"html page": {
+			url:         "https://www.noblego.de/diesel-cask-aged-robusto-zigarren/",
+			contentType: "text/html; charset=UTF-8",
+			want:        "www.noblego.de-diesel-cask-aged-robusto-zigarren-",
+		},
+		"unknown content type": {
+			url:  "https://example.com/foo?bar=baz",
+			want: "example.com-foo-",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := fixtureFileName(tt.url, tt.contentType)
+			assert.Regexp(t, "^"+tt.want+"[0-9a-f]{8}", got)
+			if tt.contentType != "" {
+				assert.Regexp(t, `\.html$`, got)
+			}
+		})
+	}
+	assert.NotEqual(t, fixtureFileName("https://example.com/foo?p=1", ""),
+		fixtureFileName("https://example.com/foo?p=2", ""))
+}
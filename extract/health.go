@@ -0,0 +1,182 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// PageType defines the kind of the source's page.
+type PageType string
+
+const (
+	// PageList the page listing the links to the detail pages, its records are read by storage.Reader's ReadBulk.
+	PageList PageType = "list"
+	// PageDetail the page describing a single cigar, its record is read by storage.Reader's Read.
+	PageDetail PageType = "detail"
+)
+
+// HealthReport defines the outcome of the extractor's health check.
+type HealthReport struct {
+	Source    string    `json:"source"`
+	PageType  PageType  `json:"pageType"`
+	CheckedAt time.Time `json:"checkedAt"`
+	// Pages the number of the pages checked.
+	Pages int `json:"pages"`
+	// Failed the number of the pages which extraction failed.
+	Failed int `json:"failed"`
+	// FillRates the share of the extracted records which have the field filled, from 0 to 1.
+	FillRates map[string]float64 `json:"fillRates"`
+	// Regressions the expected fields which coverage dropped compared with the baseline.
+	Regressions []Regression `json:"regressions,omitempty"`
+}
+
+// Regression defines the drop of the field's fill rate.
+type Regression struct {
+	Field    string  `json:"field"`
+	Baseline float64 `json:"baseline"`
+	Actual   float64 `json:"actual"`
+}
+
+// Healthy reports whether no regressions were found and at most half of the pages failed.
+func (h HealthReport) Healthy() bool {
+	return len(h.Regressions) == 0 && h.Failed*2 <= h.Pages
+}
+
+// HealthCheck defines the check of the source's extractor against a sample of pages.
+type HealthCheck struct {
+	Source Source
+	Reader storage.Reader
+	// Baseline the fill rates of the previous healthy run, the expected fields are only required to be filled once
+	// if it is not set.
+	Baseline map[string]float64
+	// Tolerance the allowed drop of the fill rate compared with the baseline, e.g., 0.1 allows 90% -> 80%.
+	Tolerance float64
+	Workers   int
+}
+
+// CheckDetails reads the records from the detail pages with the urls and compares the fill rates of the source's
+// expected fields of PageDetail with the baseline.
+func (h HealthCheck) CheckDetails(ctx context.Context, urls []string) HealthReport {
+	o := HealthReport{Source: h.Source.Name, PageType: PageDetail, Pages: len(urls)}
+	results := Map(ctx, Pool{Workers: h.Workers}, urls, func(ctx context.Context, u string) (storage.Record, error) {
+		return h.Reader.Read(ctx, u)
+	})
+	var records = make([]storage.Record, 0, len(results))
+	for _, r := range results {
+		switch r.Err {
+		case nil:
+			records = append(records, r.Value)
+		default:
+			o.Failed++
+		}
+	}
+	return h.report(o, records)
+}
+
+// CheckList reads the records from the list page and compares the fill rates of the source's expected fields
+// of PageList, or PageDetail if the former is not set, with the baseline.
+// The partial result is checked if the extraction failed.
+func (h HealthCheck) CheckList(ctx context.Context, limit, page uint) HealthReport {
+	o := HealthReport{Source: h.Source.Name, PageType: PageList, Pages: 1}
+	records, _, err := h.Reader.ReadBulk(ctx, limit, page)
+	if err != nil {
+		o.Failed++
+	}
+	return h.report(o, slices.DeleteFunc(records, storage.Record.IsEmpty))
+}
+
+func (h HealthCheck) report(o HealthReport, records []storage.Record) HealthReport {
+	expected := h.Source.Expected[o.PageType]
+	if len(expected) == 0 {
+		expected = h.Source.Expected[PageDetail]
+	}
+
+	o.CheckedAt = time.Now().UTC()
+	o.FillRates = FillRates(records, append(slices.Clone(h.Source.Fields), expected...))
+	for _, field := range expected {
+		var (
+			actual   = o.FillRates[field]
+			baseline float64
+			dropped  bool
+		)
+		switch v, ok := h.Baseline[field]; ok {
+		case true:
+			baseline = v
+			dropped = actual < baseline-h.Tolerance
+		case false:
+			// the selector is broken if the field is never filled
+			baseline = 1
+			dropped = actual == 0
+		}
+		if dropped {
+			o.Regressions = append(o.Regressions, Regression{Field: field, Baseline: baseline, Actual: actual})
+		}
+	}
+	return o
+}
+
+// FillRates returns the share of the records which have the fields filled, i.e., not zero, not nil and not empty.
+// The unknown fields are skipped.
+func FillRates(records []storage.Record, fields []string) map[string]float64 {
+	var o = make(map[string]float64, len(fields))
+	for _, field := range fields {
+		if _, ok := reflect.TypeOf(storage.Record{}).FieldByName(field); !ok {
+			continue
+		}
+		var cnt int
+		for _, r := range records {
+			if isFilled(reflect.ValueOf(r).FieldByName(field)) {
+				cnt++
+			}
+		}
+		o[field] = 0
+		if len(records) > 0 {
+			o[field] = float64(cnt) / float64(len(records))
+		}
+	}
+	return o
+}
+
+func isFilled(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() > 0
+	case reflect.Pointer:
+		return !v.IsNil() && isFilled(v.Elem())
+	default:
+		return !v.IsZero()
+	}
+}
+
+// LoadBaseline reads the fill rates of the report stored by SaveBaseline.
+func LoadBaseline(path string) (map[string]float64, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var o HealthReport
+	if err = json.Unmarshal(b, &o); err != nil {
+		return nil, fmt.Errorf("could not read the baseline %s: %w", path, err)
+	}
+	return o.FillRates, nil
+}
+
+// SaveBaseline stores the report to be used as the baseline of the next runs.
+// It fails if any page failed, because the fill rates of the partial result are not representative.
+func SaveBaseline(path string, h HealthReport) error {
+	if h.Failed > 0 {
+		return fmt.Errorf("%d of %d pages failed, the baseline is not saved", h.Failed, h.Pages)
+	}
+	h.Regressions = nil
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, b, 0640)
+	}
+	return err
+}
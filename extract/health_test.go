@@ -0,0 +1,101 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHealthReader map[string]storage.Record
+
+func (m mockHealthReader) Read(_ context.Context, id string) (storage.Record, error) {
+	if r, ok := m[id]; ok {
+		return r, nil
+	}
+	return storage.Record{}, errors.New("not found")
+}
+
+func (m mockHealthReader) ReadBulk(_ context.Context, _, _ uint) ([]storage.Record, uint, error) {
+	var o []storage.Record
+	for _, r := range m {
+		o = append(o, r)
+	}
+	return append(o, storage.Record{}), 0, nil
+}
+
+func TestFillRates(t *testing.T) {
+	color := "Maduro"
+	got := FillRates([]storage.Record{
+		{Name: "foo", Ring: 52, Color: &color, WrapperOrigin: []string{"Cuba"}},
+		{Name: "bar", Color: new(string)},
+	}, []string{"Name", "Ring", "Color", "WrapperOrigin", "Price", "Unknown"})
+	assert.Equal(t, map[string]float64{
+		"Name": 1, "Ring": 0.5, "Color": 0.5, "WrapperOrigin": 0.5, "Price": 0,
+	}, got)
+}
+
+func TestHealthCheck(t *testing.T) {
+	reader := mockHealthReader{
+		"a": {Name: "a", Ring: 52, Price: 10},
+		"b": {Name: "b", Ring: 50},
+		"c": {Name: "c", Ring: 54},
+		"d": {Name: "d", Ring: 54},
+	}
+	source := Source{
+		Name:     "foo",
+		Fields:   []string{"Name", "Ring", "Price", "Length"},
+		Expected: map[PageType][]string{PageDetail: {"Name", "Ring", "Price"}},
+	}
+
+	t.Run("healthy without baseline", func(t *testing.T) {
+		got := HealthCheck{Source: source, Reader: reader}.CheckDetails(context.TODO(), []string{"a", "b", "c", "d", "e"})
+		assert.True(t, got.Healthy())
+		assert.Equal(t, PageDetail, got.PageType)
+		assert.Equal(t, 5, got.Pages)
+		assert.Equal(t, 1, got.Failed)
+		assert.Equal(t, map[string]float64{"Name": 1, "Ring": 1, "Price": 0.25, "Length": 0}, got.FillRates)
+	})
+
+	t.Run("expected field never filled", func(t *testing.T) {
+		got := HealthCheck{Source: source, Reader: reader}.CheckDetails(context.TODO(), []string{"b", "c"})
+		assert.Equal(t, []Regression{{Field: "Price", Baseline: 1, Actual: 0}}, got.Regressions)
+	})
+
+	t.Run("coverage dropped below the baseline", func(t *testing.T) {
+		got := HealthCheck{
+			Source:    source,
+			Reader:    reader,
+			Baseline:  map[string]float64{"Name": 1, "Ring": 1, "Price": 0.5, "Length": 1},
+			Tolerance: 0.1,
+		}.CheckList(context.TODO(), 0, 1)
+		assert.Equal(t, PageList, got.PageType)
+		assert.Equal(t, []Regression{{Field: "Price", Baseline: 0.5, Actual: 0.25}}, got.Regressions)
+	})
+
+	t.Run("baseline round trip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".foo.health")
+		report := HealthCheck{Source: source, Reader: reader}.CheckDetails(context.TODO(), []string{"a", "b"})
+		assert.NoError(t, SaveBaseline(path, report))
+		got, err := LoadBaseline(path)
+		assert.NoError(t, err)
+		assert.Equal(t, report.FillRates, got)
+	})
+
+	t.Run("most pages failed", func(t *testing.T) {
+		got := HealthCheck{Source: source, Reader: reader}.CheckDetails(context.TODO(), []string{"a", "e", "f"})
+		assert.Empty(t, got.Regressions)
+		assert.False(t, got.Healthy())
+	})
+
+	t.Run("baseline with failed pages is not saved", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".foo.health")
+		report := HealthCheck{Source: source, Reader: reader}.CheckDetails(context.TODO(), []string{"a", "b", "e"})
+		assert.True(t, report.Healthy())
+		assert.Error(t, SaveBaseline(path, report))
+		assert.NoFileExists(t, path)
+	})
+}
@@ -0,0 +1,42 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+)
+
+// Metadata stamps the records read by the sources' clients with the source's name, the time when the record's page
+// was fetched, the status of its response, the extractor's version and the schema version of the record.
+// The records are not stamped if Config.Metadata is nil.
+type Metadata struct {
+	// Source the name of the data source, e.g., noblego.
+	Source string
+	// Version the extractor's version.
+	Version string
+
+	fetches *fetchLog
+}
+
+// NewMetadata initialises the Metadata for the source.
+func NewMetadata(source, version string) *Metadata {
+	return &Metadata{Source: source, Version: version, fetches: newFetchLog()}
+}
+
+// Client wraps the HTTPClient to record the time and the status of the responses, see Provenance.Client.
+func (m *Metadata) Client(c HTTPClient) HTTPClient {
+	return m.fetches.client(c)
+}
+
+// Apply sets the record's metadata, the record without URL is not stamped.
+func (m *Metadata) Apply(r *storage.Record) {
+	if m == nil || r.URL == "" {
+		return
+	}
+	f := m.fetches.pop(r.URL)
+	r.Metadata = &storage.Metadata{
+		Source:           m.Source,
+		FetchedAt:        f.at,
+		ExtractorVersion: m.Version,
+		SchemaVersion:    storage.SchemaVersion,
+		HTTPStatus:       f.status,
+	}
+}
@@ -0,0 +1,50 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Date", "Wed, 01 Jan 2025 10:00:00 GMT")
+	}))
+	defer srv.Close()
+
+	m := NewMetadata("foo", "v0.1.0")
+	resp, err := Get(context.TODO(), m.Client(srv.Client()), srv.URL+"/bar")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	t.Run("stamps the record", func(t *testing.T) {
+		r := storage.Record{Name: "bar", URL: srv.URL + "/bar"}
+		m.Apply(&r)
+		assert.Equal(t, &storage.Metadata{
+			Source:           "foo",
+			FetchedAt:        time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+			ExtractorVersion: "v0.1.0",
+			SchemaVersion:    storage.SchemaVersion,
+			HTTPStatus:       http.StatusOK,
+		}, r.Metadata)
+		assert.Empty(t, m.fetches.fetches)
+	})
+
+	t.Run("record without URL", func(t *testing.T) {
+		r := storage.Record{Name: "sampler"}
+		m.Apply(&r)
+		assert.Nil(t, r.Metadata)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var m *Metadata
+		r := storage.Record{Name: "bar", URL: srv.URL + "/bar"}
+		m.Apply(&r)
+		assert.Nil(t, r.Metadata)
+	})
+}
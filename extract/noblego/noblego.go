@@ -3,6 +3,7 @@ package noblego
 
 import (
 	"bytes"
+	"cigarsdb/extract"
 	"cigarsdb/htmlfilter"
 	"cigarsdb/storage"
 	"context"
@@ -11,6 +12,7 @@ import (
 	"io"
 	"maps"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -18,20 +20,77 @@ import (
 	"golang.org/x/net/html/atom"
 )
 
+// selectors of the noblego.de pages.
+var (
+	selectorCollateral  = htmlfilter.MustCompile("div.collateral-container")
+	selectorFreeDetails = htmlfilter.MustCompile("div.std")
+	selectorTextBlock   = htmlfilter.MustCompile("div.artikel-textblock")
+	selectorHeading     = htmlfilter.MustCompile("h3")
+	selectorVideoBlock  = htmlfilter.MustCompile("div.artikel-youtube-block")
+	selectorObject      = htmlfilter.MustCompile("object")
+	selectorIFrame      = htmlfilter.MustCompile("iframe")
+	selectorProductName = htmlfilter.MustCompile("div.product-name")
+	selectorHeadline    = htmlfilter.MustCompile("h1")
+	selectorPrices      = htmlfilter.MustCompile("ul.product-prices")
+	selectorListItem    = htmlfilter.MustCompile("li")
+	selectorPrice       = htmlfilter.MustCompile("span.price")
+	selectorPackaging   = htmlfilter.MustCompile("span.Verpackungseinheit")
+	selectorAttribute   = htmlfilter.MustCompile(`li[class*="product-attribute-"]`)
+	selectorAmount      = htmlfilter.MustCompile("p.amount")
+)
+
+func init() {
+	extract.Register(extract.Source{
+		Name:      "noblego",
+		BaseURL:   "https://www.noblego.de",
+		Languages: []string{"de"},
+		Fields: []string{
+			"Name", "URL", "Brand", "Series", "VideoURLs", "Details",
+			"Diameter", "Ring", "Length", "Format",
+			"Maker", "ManufactureOrigin", "Construction", "IsBoxpressed",
+			"WrapperOrigin", "FillerOrigin", "BinderOrigin", "WrapperTobaccoVariety",
+			"AromaProfileManufacturer", "Strength", "FlavourStrength", "SmokingDuration",
+			"Price", "AdditionalNotes",
+		},
+		Expected: map[extract.PageType][]string{
+			extract.PageDetail: {"Name", "Brand", "Diameter", "Ring", "Length", "Price"},
+		},
+		New: func(cfg extract.Config) storage.Reader {
+			return Client{HTTPClient: cfg.HTTPClient, Tracker: cfg.Tracker, Workers: cfg.Workers,
+				Provenance: cfg.Provenance, Metadata: cfg.Metadata}
+		},
+	})
+}
+
 // Client defines the client to noblego.de to fetch data from.
 type Client struct {
 	HTTPClient HTTPClient
+	Tracker    extract.Tracker
+	// Workers the max number of the detail pages fetched concurrently.
+	Workers int
+	// Provenance completes the fields' provenance, it is dropped if nil.
+	Provenance *extract.Provenance
+	// Metadata stamps the records with the extraction metadata.
+	Metadata *extract.Metadata
 }
 
-func (c Client) Read(_ context.Context, id string) (r storage.Record, err error) {
+func (c Client) Read(ctx context.Context, id string) (r storage.Record, err error) {
 	var resp *http.Response
-	if resp, err = c.HTTPClient.Get(id); err == nil {
-		err = readDetailsPage(resp.Body, &r)
+	if resp, err = extract.Get(ctx, c.HTTPClient, id); err == nil {
+		if err = extract.CheckStatus(resp, id); err == nil {
+			err = extract.AttachURL(readDetailsPage(resp.Body, &r), id)
+		}
 		_ = resp.Body.Close()
 		if err == nil {
 			r.URL = id
+			if isSampler(r.Name) {
+				r = storage.Record{}
+				err = &extract.NotACigarError{URL: id, Kind: "sampler"}
+			}
 		}
 	}
+	c.Provenance.Apply(&r)
+	c.Metadata.Apply(&r)
 	return r, err
 }
 
@@ -43,6 +102,7 @@ func readDetailsPage(v io.ReadCloser, o *storage.Record) error {
 	if doc, err = html.Parse(v); err == nil {
 		n := htmlfilter.Node{Node: doc}
 		o.Name = readName(n)
+		o.SetProvenance("Name", storage.Provenance{Selector: "div.product-name h1", Raw: o.Name})
 		err = readAttributes(n, o)
 		err = errors.Join(err, readPrice(n, o))
 		readFreeDetails(n, o)
@@ -52,17 +112,16 @@ func readDetailsPage(v io.ReadCloser, o *storage.Record) error {
 
 func readFreeDetails(n htmlfilter.Node, o *storage.Record) {
 	var tmp = make(map[string]string)
-	for nn := range n.Find("div.collateral-container") {
-		for nnn := range nn.Find("div.std") {
+	for nn := range selectorCollateral.Find(n) {
+		for nnn := range selectorFreeDetails.Find(nn) {
 			if found := readVideoURL(nnn, o); found {
-				for x := range nnn.Find("div.artikel-textblock") {
+				for x := range selectorTextBlock.Find(nnn) {
 					nnn = x
 					break
 				}
 			}
-			for detail := range nnn.Find("h3") {
-				if detail.LastChild != nil {
-					k := detail.LastChild.Data
+			for detail := range selectorHeading.Find(nnn) {
+				if k := detail.Text(); k != "" {
 					var val bytes.Buffer
 					s := detail.Node
 					for s.NextSibling != nil {
@@ -74,7 +133,7 @@ func readFreeDetails(n htmlfilter.Node, o *storage.Record) {
 							if val.Len() > 0 {
 								_, _ = val.WriteString("\n")
 							}
-							_, _ = val.WriteString(strings.TrimSpace(htmlfilter.InnerHTML(s)))
+							_, _ = val.WriteString(htmlfilter.Node{Node: s}.Markdown())
 						}
 					}
 					if val.Len() > 0 {
@@ -92,12 +151,12 @@ func readFreeDetails(n htmlfilter.Node, o *storage.Record) {
 
 func readVideoURL(n htmlfilter.Node, o *storage.Record) bool {
 	var found bool
-	for video := range n.Find("div.artikel-youtube-block") {
+	for video := range selectorVideoBlock.Find(n) {
 		var videoNode htmlfilter.Node
-		for videoNode = range video.Find("object") {
+		for videoNode = range selectorObject.Find(video) {
 		}
 		if videoNode.Node == nil {
-			for videoNode = range n.Find("iframe") {
+			for videoNode = range selectorIFrame.Find(n) {
 				if videoNode.Node != nil {
 					break
 				}
@@ -112,10 +171,8 @@ func readVideoURL(n htmlfilter.Node, o *storage.Record) bool {
 			case atom.Iframe:
 				keyRef = "src"
 			}
-			for _, att := range videoNode.Attr {
-				if att.Key == keyRef {
-					videoURL = newVideoURL(att.Val)
-				}
+			if v, ok := videoNode.Attr(keyRef); ok {
+				videoURL = newVideoURL(v)
 			}
 			if videoURL != "" {
 				o.VideoURLs = append(o.VideoURLs, videoURL)
@@ -156,11 +213,9 @@ func newVideoURL(s string) string {
 
 func readName(n htmlfilter.Node) string {
 	var o string
-	for n = range n.Find("div.product-name") {
-		for name := range n.Find("h1") {
-			if name.LastChild != nil {
-				o = name.LastChild.Data
-			}
+	for n = range selectorProductName.Find(n) {
+		for name := range selectorHeadline.Find(n) {
+			o = name.OwnText()
 		}
 	}
 	return o
@@ -171,32 +226,37 @@ func readPrice(n htmlfilter.Node, o *storage.Record) error {
 
 	var lastPriceOption htmlfilter.Node
 	var extracted bool
-	for nn := range n.Find("ul.product-prices") {
+	for nn := range selectorPrices.Find(n) {
 		if extracted {
 			break
 		}
-		for lastPriceOption = range nn.Find("li") {
+		for lastPriceOption = range selectorListItem.Find(nn) {
 		}
 		extracted = true
 	}
 
-	var cost float64
-	for nnn := range lastPriceOption.Find("span.price") {
-		tmp := nnn.LastChild.Data
-		// remove length of euro sign with the unbreakable space
-		tmp = tmp[:len(tmp)-5]
+	var (
+		cost float64
+		raw  string
+	)
+	for nnn := range selectorPrice.Find(lastPriceOption) {
+		raw = nnn.Text()
+		tmp := strings.TrimSpace(strings.TrimSuffix(raw, "€"))
 		tmp = strings.ReplaceAll(tmp, ",", ".")
 		cost, _ = strconv.ParseFloat(tmp, 64)
 	}
 
 	var quantity = 1
-	for packaging := range lastPriceOption.Find("span.Verpackungseinheit") {
-		if strings.HasSuffix(packaging.LastChild.Data, "er") {
-			quantity, err = strconv.Atoi(strings.TrimSuffix(packaging.LastChild.Data, "er"))
+	for packaging := range selectorPackaging.Find(lastPriceOption) {
+		if v := packaging.Text(); strings.HasSuffix(v, "er") {
+			if quantity, err = strconv.Atoi(strings.TrimSuffix(v, "er")); err != nil {
+				err = &extract.ParseError{Field: "Price", Selector: "span.Verpackungseinheit", Value: v, Err: err}
+			}
 		}
 	}
 	if err == nil {
 		o.Price = cost / float64(quantity)
+		o.SetProvenance("Price", storage.Provenance{Selector: "ul.product-prices li span.price", Raw: raw})
 	}
 
 	return err
@@ -204,8 +264,8 @@ func readPrice(n htmlfilter.Node, o *storage.Record) error {
 
 func readAttributes(n htmlfilter.Node, o *storage.Record) error {
 	var err error
-	for nn := range n.Find("li.product-attribute-*") {
-		for _, attr := range nn.Attr {
+	for nn := range selectorAttribute.Find(n) {
+		for _, attr := range nn.Node.Attr {
 			if attr.Key == "class" {
 				var er error
 
@@ -302,8 +362,17 @@ func readAttributes(n htmlfilter.Node, o *storage.Record) error {
 					o.AdditionalNotes = v
 				}
 
-				if er != nil {
-					err = errors.Join(err, fmt.Errorf("attributes extraction error: %w", er))
+				switch field, ok := attributeFields[attr.Val]; {
+				case er != nil:
+					err = errors.Join(err, &extract.ParseError{
+						Field:    field,
+						Selector: "li." + attr.Val,
+						Err:      er,
+					})
+				case ok:
+					if raw := rawData(val); raw != nil {
+						o.SetProvenance(field, storage.Provenance{Selector: "li." + attr.Val, Raw: *raw})
+					}
 				}
 			}
 		}
@@ -311,6 +380,29 @@ func readAttributes(n htmlfilter.Node, o *storage.Record) error {
 	return err
 }
 
+// attributeFields maps the attributes to the storage.Record fields.
+var attributeFields = map[string]string{
+	"product-attribute-brand":               "Brand",
+	"product-attribute-series":              "Series",
+	"product-attribute-cig_diameter":        "Diameter",
+	"product-attribute-cig_gauge":           "Ring",
+	"product-attribute-cig_length":          "Length",
+	"product-attribute-cig_size":            "Format",
+	"product-attribute-cig_maker":           "Maker",
+	"product-attribute-herkunft":            "ManufactureOrigin",
+	"product-attribute-cig_construction":    "Construction",
+	"product-attribute-cig_form":            "IsBoxpressed",
+	"product-attribute-cig_wrapper_origin":  "WrapperOrigin",
+	"product-attribute-cig_filler":          "FillerOrigin",
+	"product-attribute-cig_binder":          "BinderOrigin",
+	"product-attribute-cig_wrapper_tobacco": "WrapperTobaccoVariety",
+	"product-attribute-cig_aroma":           "AromaProfileManufacturer",
+	"product-attribute-strength":            "Strength",
+	"product-attribute-flavour_strength":    "FlavourStrength",
+	"product-attribute-cig_duration":        "SmokingDuration",
+	"product-attribute-cig_special":         "AdditionalNotes",
+}
+
 func spanReader(n *html.Node, fn func(n *html.Node) *string) (o *string) {
 	for c := range n.ChildNodes() {
 		for _, att := range c.Attr {
@@ -325,8 +417,14 @@ func spanReader(n *html.Node, fn func(n *html.Node) *string) (o *string) {
 
 func dataFromFirstSpanChild(n *html.Node) *string {
 	return spanReader(n, func(n *html.Node) *string {
-		s := strings.TrimSpace(n.FirstChild.Data)
-		return pointer(s)
+		return pointer(htmlfilter.Node{Node: n}.OwnText())
+	})
+}
+
+// rawData reads the attribute's value as displayed on the page.
+func rawData(n *html.Node) *string {
+	return spanReader(n, func(n *html.Node) *string {
+		return pointer(htmlfilter.Node{Node: n}.Text())
 	})
 }
 
@@ -334,7 +432,7 @@ func dataFromATagText(n *html.Node) *string {
 	return spanReader(n, func(n *html.Node) *string {
 		for nnn := range n.Descendants() {
 			if nnn.DataAtom == atom.A {
-				return pointer(strings.TrimSpace(nnn.LastChild.Data))
+				return pointer(htmlfilter.Node{Node: nnn}.Text())
 			}
 		}
 		return nil
@@ -361,29 +459,33 @@ func (c Client) ReadBulk(ctx context.Context, limit, page uint) (r []storage.Rec
 	}
 	u := baseURL + fmt.Sprintf("&limit=%d&p=%d", limit, page)
 	var resp *http.Response
-	if resp, err = c.HTTPClient.Get(u); err == nil {
+	if resp, err = extract.Get(ctx, c.HTTPClient, u); err == nil {
+		if err = extract.CheckStatus(resp, u); err != nil {
+			_ = resp.Body.Close()
+			return nil, 0, err
+		}
 		var totalItems uint
 		var urlItems []string
 		totalItems, urlItems, err = readItemsFromListPage(resp.Body)
 		_ = resp.Body.Close()
 
 		if err == nil && dataInCurrentPage(page, limit, totalItems) {
-			maxN := len(urlItems)
-			r = make([]storage.Record, maxN)
-			var flags = make(chan struct{}, maxN)
-			for i, u := range urlItems {
-				go func() {
-					var er error
-					if r[i], er = c.Read(ctx, u); er != nil {
-						err = errors.Join(err, fmt.Errorf("error reading details using %s: %w", u, er))
+			extract.Plan(c.Tracker, urlItems...)
+			results := extract.Map(ctx, extract.Pool{Workers: c.Workers}, urlItems,
+				func(ctx context.Context, u string) (storage.Record, error) {
+					rec, er := c.Read(ctx, u)
+					extract.Track(c.Tracker, u, er)
+					if er != nil {
+						er = fmt.Errorf("error reading details using %s: %w", u, er)
 					}
-					flags <- struct{}{}
-				}()
-			}
-			for maxN > 0 {
-				<-flags
-				maxN--
-			}
+					return rec, er
+				})
+			// the detail pages which are not cigars are skipped, they have no records
+			results = slices.DeleteFunc(results, func(v extract.Result[storage.Record]) bool {
+				return errors.As(v.Err, new(*extract.NotACigarError))
+			})
+			r = extract.Values(results)
+			err = extract.JoinErrors(results)
 			if dataInCurrentPage(page+1, limit, totalItems) {
 				nextPage = page + 1
 			}
@@ -415,26 +517,24 @@ func readItemsFromListPage(v io.ReadCloser) (totalItems uint, urls []string, err
 	return totalItems, urls, err
 }
 
+var selectorItemURL = htmlfilter.MustCompile("li.item h2.product-name a")
+
 func readURLs(n htmlfilter.Node) []string {
 	var o []string
-	for nn := range n.Find("li.item") {
-		for nn = range nn.Find("h2.product-name") {
-			for a := range nn.Find("a") {
-				if !skipItem(a) {
-					for _, att := range a.Attr {
-						if att.Key == "href" {
-							o = append(o, att.Val)
-						}
-					}
-				}
-			}
+	for a := range selectorItemURL.All(n) {
+		if u, ok := a.Href(""); ok && !skipItem(a) {
+			o = append(o, u)
 		}
 	}
 	return o
 }
 
 func skipItem(n htmlfilter.Node) bool {
-	return n.LastChild != nil && strings.Contains(strings.ToLower(n.LastChild.Data), "sampler")
+	return isSampler(n.Text())
+}
+
+func isSampler(name string) bool {
+	return strings.Contains(strings.ToLower(name), "sampler")
 }
 
 func readTotalNumberOfItems(n htmlfilter.Node) (uint, error) {
@@ -442,11 +542,13 @@ func readTotalNumberOfItems(n htmlfilter.Node) (uint, error) {
 		o   uint
 		err error
 	)
-	for n = range n.Find("p.amount") {
-		s := strings.TrimSpace(strings.Split(n.LastChild.Data, " ")[0])
+	for n = range selectorAmount.Find(n) {
+		s := strings.Split(n.Text(), " ")[0]
 		var tmp uint64
 		if tmp, err = strconv.ParseUint(s, 10, 64); err == nil {
 			o = uint(tmp)
+		} else {
+			err = &extract.ParseError{Field: "total number of items", Selector: "p.amount", Value: s, Err: err}
 		}
 		break
 	}
@@ -455,6 +557,7 @@ func readTotalNumberOfItems(n htmlfilter.Node) (uint, error) {
 
 type HTTPClient interface {
 	Get(url string) (*http.Response, error)
+	Do(req *http.Request) (*http.Response, error)
 }
 
 func pointer[V string | bool | float64 | int](s V) *V {
@@ -2,6 +2,7 @@ package noblego
 
 import (
 	"bytes"
+	"cigarsdb/extract"
 	"cigarsdb/storage"
 	"context"
 	_ "embed"
@@ -13,7 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-//go:embed testdata/details-diesel-crucible-toro.html
+//go:embed testdata/www.noblego.de-diesel-crucible-toro-zigarren-05dde557.html
 var detailsDieselCrucibleToro []byte
 var wantDieselCrucibleToro = storage.Record{
 	Name:              "Diesel Crucible Limited Edition 2021 Toro",
@@ -50,14 +51,17 @@ var wantDieselCrucibleToro = storage.Record{
 			"Aromaverlauf bringt wunderbar abwechslungsfreudige Espresso-, Zartbitterschokolade- und Nougatnoten hervor, " +
 			"die mal von delikaten Tönen gerösteter Nüsse oder pfeffrigen Nuancen begleitet werden. " +
 			"Ein intensiv vollmundiger Zigarrengenuss für gut 90 Minuten.",
-		"Nice to know": "Importiert werden die Zigarren der Marke Diesel von der AKRA Kotschenreuther GmbH mit Sitz in Langenzenn/Bayern. Zurzeit sind mit den <a href=\"/diesel-cask-aged-zigarren/\" title=\"Die Diesel Cask Aged Zigarren kennenlernen! \" target=\"_blank\">Cask Aged</a> und <a href=\"/diesel-barrel-aged-zigarren/\" title=\"Die Diesel Barrel Aged Zigarren kennenlernen! \" target=\"_blank\">Barrel Aged</a> Zigarren zwei regulär erscheinende Serien der Marke Diesel in unserem Online-Shop erhältlich.",
+		"Nice to know": "Importiert werden die Zigarren der Marke Diesel von der AKRA Kotschenreuther GmbH mit Sitz " +
+			"in Langenzenn/Bayern. Zurzeit sind mit den [Cask Aged](/diesel-cask-aged-zigarren/) und " +
+			"[Barrel Aged](/diesel-barrel-aged-zigarren/) Zigarren zwei regulär erscheinende Serien der Marke " +
+			"Diesel in unserem Online-Shop erhältlich.",
 		"Resümee": "Eine gelungene Zigarre mit viel Tiefgang! Erfahrenen Gaumen bereitet die Diesel " +
 			"Crucible Limited Edition 2021 ein köstliches Genusserlebnis bis zum letzten Aschefall. " +
 			"Jetzt bestellen, solange der Vorrat reicht!",
 	},
 }
 
-//go:embed testdata/details-diesel-cask-aged-robusto.html
+//go:embed testdata/www.noblego.de-diesel-cask-aged-robusto-zigarren-152f095d.html
 var detailsDieselCaskAgedRobusto []byte
 var wantDieselCaskAgedRobusto = storage.Record{
 	Name:                  "Diesel Cask Aged Robusto",
@@ -221,7 +225,11 @@ func (m mockHttp) Get(url string) (*http.Response, error) {
 	return r, m.Err
 }
 
-//go:embed testdata/list.html
+func (m mockHttp) Do(req *http.Request) (*http.Response, error) {
+	return m.Get(req.URL.String())
+}
+
+//go:embed testdata/www.noblego.de-zigarren-1e5e63ea.html
 var listData []byte
 
 func TestClient_ReadBulk(t *testing.T) {
@@ -247,6 +255,52 @@ func TestClient_ReadBulk(t *testing.T) {
 	}
 }
 
+func TestClient_ReadBulk_sampler(t *testing.T) {
+	sampler := bytes.Replace(detailsDieselCaskAgedRobusto, []byte(">Diesel Cask Aged Robusto</h1>"),
+		[]byte(">Diesel Sampler</h1>"), 1)
+	tracker := extract.NewCheckpoint("noblego")
+	c := Client{HTTPClient: mockHttp{
+		Body: io.NopCloser(bytes.NewReader(listData)),
+		BodyRoute: map[string]io.ReadCloser{
+			"https://www.noblego.de/diesel-cask-aged-robusto-zigarren/": io.NopCloser(bytes.NewReader(sampler)),
+			"https://www.noblego.de/diesel-crucible-toro-zigarren/": io.NopCloser(bytes.NewReader(
+				detailsDieselCrucibleToro)),
+		},
+	}, Tracker: tracker}
+	got, _, err := c.ReadBulk(context.TODO(), 0, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		recordsEqual(t, wantDieselCrucibleToro, got[0])
+	}
+	// the sampler has no record to persist
+	assert.Equal(t, []string{"https://www.noblego.de/diesel-crucible-toro-zigarren/"}, tracker.Pending())
+}
+
+func TestClient_ReadBulk_cancelled(t *testing.T) {
+	c, err := extract.LoadFixture("testdata")
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = Client{HTTPClient: c}.ReadBulk(ctx, 0, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestClient_ReadBulk_replay replays the runs recorded with the flag -record to the testdata directory.
+func TestClient_ReadBulk_replay(t *testing.T) {
+	c, err := extract.LoadFixture("testdata")
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, p := range c.Manifest.Pages {
+		got, nextPage, err := Client{HTTPClient: c}.ReadBulk(context.TODO(), p.Limit, p.Page)
+		assert.NoError(t, err)
+		assert.Equal(t, p.NextPage, nextPage)
+		assert.Equal(t, p.Records, got)
+	}
+}
+
 //go:embed testdata/details-rocky-patel-vintage-connecticut-1999.html
 var detailsRockyPatelVintageConnecticut1999 []byte
 
@@ -0,0 +1,112 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// DefaultWorkers defines the number of the pool's workers if it is not set.
+const DefaultWorkers = 8
+
+// PoolMode defines how the pool treats the failed items.
+type PoolMode int
+
+const (
+	// CollectAll processes every item and collects the errors of the failed items.
+	CollectAll PoolMode = iota
+	// FailFast stops processing the items after the first failure:
+	// the context of the in-flight items is cancelled, and the remaining items are not started.
+	FailFast
+)
+
+// Pool defines the concurrent processing of the items using the bounded number of workers.
+type Pool struct {
+	// Workers the max number of the items processed concurrently, it defaults to DefaultWorkers.
+	Workers int
+	Mode    PoolMode
+}
+
+// Result defines the outcome of the item's processing.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Map processes the items using fn concurrently. The results are ordered as the items.
+// The items which were not started because the context was done, or the pool failed fast hold the context's error.
+func Map[I, O any](ctx context.Context, p Pool, items []I, fn func(ctx context.Context, item I) (O, error)) []Result[O] {
+	var o = make([]Result[O], len(items))
+	if len(items) == 0 {
+		return o
+	}
+
+	workers := p.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	workers = min(workers, len(items))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		next = make(chan int)
+		wg   = new(sync.WaitGroup)
+	)
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					o[i].Err = err
+					continue
+				}
+				v, err := fn(ctx, items[i])
+				o[i] = Result[O]{Value: v, Err: err}
+				if err != nil && p.Mode == FailFast {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	var sent int
+dispatch:
+	for sent < len(items) && ctx.Err() == nil {
+		select {
+		case next <- sent:
+			sent++
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	for i := sent; i < len(items); i++ {
+		o[i].Err = ctx.Err()
+	}
+	return o
+}
+
+// Values returns the values of the results.
+func Values[T any](v []Result[T]) []T {
+	var o = make([]T, len(v))
+	for i, r := range v {
+		o[i] = r.Value
+	}
+	return o
+}
+
+// JoinErrors joins the errors of the results, the error is nil if all items succeeded.
+func JoinErrors[T any](v []Result[T]) error {
+	var errs []error
+	for _, r := range v {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
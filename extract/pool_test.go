@@ -0,0 +1,75 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	items := []int{5, 4, 3, 2, 1, 0}
+
+	t.Run("results are ordered as items", func(t *testing.T) {
+		var inFlight, maxInFlight atomic.Int32
+		got := Map(context.TODO(), Pool{Workers: 2}, items, func(_ context.Context, v int) (string, error) {
+			n := inFlight.Add(1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			// the first items finish last
+			time.Sleep(time.Duration(v) * time.Millisecond)
+			inFlight.Add(-1)
+			return fmt.Sprintf("%d", v), nil
+		})
+		assert.Equal(t, []string{"5", "4", "3", "2", "1", "0"}, Values(got))
+		assert.NoError(t, JoinErrors(got))
+		assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+	})
+
+	errOdd := errors.New("odd")
+	fn := func(_ context.Context, v int) (int, error) {
+		if v%2 == 1 {
+			return 0, errOdd
+		}
+		return v * 10, nil
+	}
+
+	t.Run("collect all", func(t *testing.T) {
+		got := Map(context.TODO(), Pool{Workers: 3}, items, fn)
+		assert.Equal(t, []Result[int]{
+			{Err: errOdd}, {Value: 40}, {Err: errOdd}, {Value: 20}, {Err: errOdd}, {Value: 0},
+		}, got)
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		got := Map(context.TODO(), Pool{Workers: 1, Mode: FailFast}, items, fn)
+		assert.ErrorIs(t, got[0].Err, errOdd)
+		for _, r := range got[1:] {
+			assert.ErrorIs(t, r.Err, context.Canceled)
+		}
+	})
+
+	t.Run("items are not started once the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var started atomic.Int32
+		got := Map(ctx, Pool{}, items, func(context.Context, int) (int, error) {
+			started.Add(1)
+			return 0, nil
+		})
+		assert.Zero(t, started.Load())
+		assert.ErrorIs(t, JoinErrors(got), context.Canceled)
+	})
+
+	t.Run("no items", func(t *testing.T) {
+		assert.Empty(t, Map(context.TODO(), Pool{}, []int(nil), fn))
+	})
+}
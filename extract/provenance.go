@@ -0,0 +1,138 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Provenance completes the fields' provenance captured by the sources' clients with the source's name,
+// the page's URL, the extractor's version and the time when the page was fetched.
+// The sources' clients drop the captured provenance if Config.Provenance is nil.
+type Provenance struct {
+	// Source the name of the data source, e.g., noblego.
+	Source string
+	// Version the extractor's version.
+	Version string
+
+	fetches *fetchLog
+}
+
+// NewProvenance initialises the Provenance for the source.
+func NewProvenance(source, version string) *Provenance {
+	return &Provenance{Source: source, Version: version, fetches: newFetchLog()}
+}
+
+// Client wraps the HTTPClient to record the time when the pages were fetched.
+// The time is read from the response's Date header, which is preserved for the cached responses,
+// or is the time of the response otherwise.
+func (p *Provenance) Client(c HTTPClient) HTTPClient {
+	return p.fetches.client(c)
+}
+
+// Apply completes the provenance of the record's fields, or drops it if p is nil.
+func (p *Provenance) Apply(r *storage.Record) {
+	if p == nil {
+		r.Provenance = nil
+		return
+	}
+	var urls = make(map[string]fetch, 1)
+	for field, v := range r.Provenance {
+		if v.URL == "" {
+			v.URL = r.URL
+		}
+		f, ok := urls[v.URL]
+		if !ok {
+			f = p.fetches.pop(v.URL)
+			urls[v.URL] = f
+		}
+		v.Source = p.Source
+		v.ExtractorVersion = p.Version
+		v.FetchedAt = f.at
+		r.Provenance[field] = v
+	}
+}
+
+// maxFetches the number of the responses kept by fetchLog, the oldest ones are evicted,
+// e.g., of the list pages and of the failed detail pages, which records are never applied.
+const maxFetches = 4096
+
+// fetchLog records the time and the status of the responses by the requested URL.
+// The response is removed once it is used, see fetchLog.pop.
+type fetchLog struct {
+	mu      sync.Mutex
+	fetches map[string]fetch
+	// order the URLs in the order of the responses to evict the oldest ones.
+	order []logged
+	seq   uint64
+}
+
+type fetch struct {
+	at     time.Time
+	status int
+	seq    uint64
+}
+
+type logged struct {
+	url string
+	seq uint64
+}
+
+func newFetchLog() *fetchLog {
+	return &fetchLog{fetches: make(map[string]fetch)}
+}
+
+func (l *fetchLog) client(c HTTPClient) HTTPClient {
+	return fetchLogClient{HTTPClient: c, log: l}
+}
+
+func (l *fetchLog) add(url string, f fetch) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq++
+	f.seq = l.seq
+	l.fetches[url] = f
+	l.order = append(l.order, logged{url: url, seq: f.seq})
+	for len(l.order) > maxFetches {
+		// the URL fetched again is evicted with its latest response only
+		if v, ok := l.fetches[l.order[0].url]; ok && v.seq == l.order[0].seq {
+			delete(l.fetches, l.order[0].url)
+		}
+		l.order = l.order[1:]
+	}
+}
+
+// pop returns the response of the URL and removes it from the log.
+func (l *fetchLog) pop(url string) fetch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	o := l.fetches[url]
+	delete(l.fetches, url)
+	return o
+}
+
+type fetchLogClient struct {
+	HTTPClient HTTPClient
+	log        *fetchLog
+}
+
+func (c fetchLogClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+func (c fetchLogClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err == nil {
+		t, er := http.ParseTime(resp.Header.Get("Date"))
+		if er != nil {
+			t = time.Now()
+		}
+		c.log.add(req.URL.String(), fetch{at: t.UTC(), status: resp.StatusCode})
+	}
+	return resp, err
+}
@@ -0,0 +1,66 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenance(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Date", "Wed, 01 Jan 2025 10:00:00 GMT")
+	}))
+	defer srv.Close()
+
+	p := NewProvenance("foo", "v0.1.0")
+	resp, err := Get(context.TODO(), p.Client(srv.Client()), srv.URL+"/bar")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	t.Run("completes the captured provenance", func(t *testing.T) {
+		r := storage.Record{Name: "bar", URL: srv.URL + "/bar", Provenance: map[string]storage.Provenance{
+			"Name": {Selector: "h1", Raw: " bar "},
+		}}
+		p.Apply(&r)
+		assert.Equal(t, map[string]storage.Provenance{
+			"Name": {
+				Source:           "foo",
+				URL:              srv.URL + "/bar",
+				Selector:         "h1",
+				Raw:              " bar ",
+				ExtractorVersion: "v0.1.0",
+				FetchedAt:        time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+			},
+		}, r.Provenance)
+		assert.Empty(t, p.fetches.fetches)
+	})
+
+	t.Run("drops the provenance if disabled", func(t *testing.T) {
+		var p *Provenance
+		r := storage.Record{Name: "bar", Provenance: map[string]storage.Provenance{"Name": {Raw: "bar"}}}
+		p.Apply(&r)
+		assert.Nil(t, r.Provenance)
+	})
+}
+
+func TestFetchLog(t *testing.T) {
+	l := newFetchLog()
+	for i := range maxFetches + 1 {
+		l.add(strconv.Itoa(i), fetch{status: http.StatusOK})
+	}
+	// the URL 1 is fetched again before it is evicted, its latest response is kept
+	l.add("1", fetch{status: http.StatusNotModified})
+	l.add("foo", fetch{status: http.StatusOK})
+
+	assert.Len(t, l.fetches, maxFetches)
+	assert.Zero(t, l.pop("0"))
+	assert.Equal(t, http.StatusNotModified, l.pop("1").status)
+	assert.Equal(t, http.StatusOK, l.pop("foo").status)
+	assert.Zero(t, l.pop("foo"))
+}
@@ -0,0 +1,154 @@
+package extract
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// RatePolicy defines the politeness policy applied to every host independently.
+type RatePolicy struct {
+	// RequestsPerSecond the sustained rate of requests, the rate is not limited if zero.
+	RequestsPerSecond float64
+	// Burst the number of requests which can be sent at once, it defaults to one.
+	Burst int
+	// MaxInFlight the max number of concurrent requests, the number is not limited if zero.
+	MaxInFlight int
+}
+
+// RateLimitedClient wraps the HTTPClient to throttle the requests using the token bucket per host.
+// The in-flight request slot is released once the response headers are received.
+type RateLimitedClient struct {
+	HTTPClient HTTPClient
+	Policy     RatePolicy
+
+	mu    *sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+// NewRateLimitedClient initialises the client which throttles the requests sent by c according to the policy.
+func NewRateLimitedClient(c HTTPClient, p RatePolicy) *RateLimitedClient {
+	if p.Burst < 1 {
+		p.Burst = 1
+	}
+	return &RateLimitedClient{
+		HTTPClient: c,
+		Policy:     p,
+		mu:         new(sync.Mutex),
+		hosts:      make(map[string]*hostLimiter),
+	}
+}
+
+func (c *RateLimitedClient) Get(u string) (*http.Response, error) {
+	var host string
+	if v, err := url.Parse(u); err == nil {
+		host = v.Host
+	}
+	release, err := c.limiter(host).acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.HTTPClient.Get(u)
+}
+
+func (c *RateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+	release, err := c.limiter(req.URL.Host).acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.HTTPClient.Do(req)
+}
+
+func (c *RateLimitedClient) limiter(host string) *hostLimiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.hosts[host]
+	if !ok {
+		l = newHostLimiter(c.Policy)
+		c.hosts[host] = l
+	}
+	return l
+}
+
+type hostLimiter struct {
+	rate     float64
+	burst    float64
+	inFlight chan struct{}
+
+	mu     *sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newHostLimiter(p RatePolicy) *hostLimiter {
+	l := &hostLimiter{
+		rate:   p.RequestsPerSecond,
+		burst:  float64(p.Burst),
+		mu:     new(sync.Mutex),
+		tokens: float64(p.Burst),
+		last:   time.Now(),
+	}
+	if p.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, p.MaxInFlight)
+	}
+	return l
+}
+
+// acquire blocks until the request is allowed to be sent, or the context is done.
+// The returned function must be called when the request is completed.
+func (l *hostLimiter) acquire(ctx context.Context) (release func(), err error) {
+	release = func() {}
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+			release = func() { <-l.inFlight }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if err = sleep(ctx, l.reserve()); err != nil {
+		release()
+		release = nil
+	}
+	return release, err
+}
+
+// reserve takes the token from the bucket and returns the delay until the token becomes available.
+func (l *hostLimiter) reserve() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	return delay
+}
+
+// sleep pauses the current goroutine for the given duration, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,85 @@
+package extract
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingHTTP struct {
+	inFlight    *atomic.Int32
+	maxInFlight *atomic.Int32
+	delay       time.Duration
+}
+
+func (m blockingHTTP) Get(_ string) (*http.Response, error) {
+	n := m.inFlight.Add(1)
+	for {
+		v := m.maxInFlight.Load()
+		if n <= v || m.maxInFlight.CompareAndSwap(v, n) {
+			break
+		}
+	}
+	time.Sleep(m.delay)
+	m.inFlight.Add(-1)
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func (m blockingHTTP) Do(req *http.Request) (*http.Response, error) {
+	return m.Get(req.URL.String())
+}
+
+func TestRateLimitedClient(t *testing.T) {
+	t.Run("burst is sent at once, the rest is throttled", func(t *testing.T) {
+		c := NewRateLimitedClient(MockHTTP{}, RatePolicy{RequestsPerSecond: 20, Burst: 2})
+		start := time.Now()
+		for range 4 {
+			_, err := c.Get("https://foo.bar/baz")
+			assert.NoError(t, err)
+		}
+		// two requests are sent immediately, two are delayed by 1/20 s each
+		assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	})
+
+	t.Run("hosts are throttled independently", func(t *testing.T) {
+		c := NewRateLimitedClient(MockHTTP{}, RatePolicy{RequestsPerSecond: 1})
+		start := time.Now()
+		for _, u := range []string{"https://foo.bar/baz", "https://qux.bar/baz", "https://quux.bar/baz"} {
+			_, err := c.Get(u)
+			assert.NoError(t, err)
+		}
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("in-flight requests are capped", func(t *testing.T) {
+		m := blockingHTTP{inFlight: new(atomic.Int32), maxInFlight: new(atomic.Int32), delay: 20 * time.Millisecond}
+		c := NewRateLimitedClient(m, RatePolicy{MaxInFlight: 2})
+		var wg sync.WaitGroup
+		for range 6 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = c.Get("https://foo.bar/baz")
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(2), m.maxInFlight.Load())
+	})
+
+	t.Run("waiting is aborted when the context is done", func(t *testing.T) {
+		c := NewRateLimitedClient(MockHTTP{}, RatePolicy{RequestsPerSecond: 0.1})
+		_, err := c.Get("https://foo.bar/baz")
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://foo.bar/baz", nil)
+		_, err = c.Do(req)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
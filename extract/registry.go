@@ -0,0 +1,116 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"cmp"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// Config defines the dependencies shared by the sources' clients.
+type Config struct {
+	HTTPClient HTTPClient
+	Dumper     storage.Writer
+	Logs       *slog.Logger
+	Tracker    Tracker
+	// Workers the max number of the detail pages fetched concurrently, see Pool.
+	Workers int
+	// Provenance completes the fields' provenance, it is not captured if nil.
+	Provenance *Provenance
+	// Metadata stamps the records with the extraction metadata, it is not set if nil.
+	Metadata *Metadata
+}
+
+// Source defines the data source's metadata and the constructor of its client.
+type Source struct {
+	// Name unique identifier of the source, e.g., noblego.
+	Name string
+	// BaseURL the root URL of the source website.
+	BaseURL string
+	// Languages ISO 639-1 codes of the languages used on the website.
+	Languages []string
+	// Fields the names of the storage.Record fields which the source fills.
+	Fields []string
+	// Expected the names of the storage.Record fields which are expected to be filled on every page of the type,
+	// the health check fails when their coverage drops, see HealthCheck.
+	Expected map[PageType][]string
+	// New initialises the client to read the data from the source.
+	New func(cfg Config) storage.Reader
+}
+
+var registry = struct {
+	mu      *sync.RWMutex
+	sources map[string]Source
+}{
+	mu:      new(sync.RWMutex),
+	sources: make(map[string]Source),
+}
+
+// Register makes the source available by its name.
+// It panics if the name, or the constructor is missing, or if the source with the same name was registered before.
+// The function is meant to be called from the init function of the package which implements the source's client.
+func Register(s Source) {
+	register(s, false)
+}
+
+// Replace makes the source available by its name replacing the source registered before with the same name.
+// It panics if the name, or the constructor is missing.
+func Replace(s Source) {
+	register(s, true)
+}
+
+func register(s Source, replace bool) {
+	if s.Name == "" {
+		panic("extract: source name must be provided")
+	}
+	if s.New == nil {
+		panic("extract: constructor must be provided for the source " + s.Name)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.sources[s.Name]; ok && !replace {
+		panic("extract: source registered twice " + s.Name)
+	}
+	registry.sources[s.Name] = s
+}
+
+// Lookup returns the registered source by its name.
+func Lookup(name string) (Source, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	s, ok := registry.sources[name]
+	return s, ok
+}
+
+// Sources returns all registered sources sorted by name.
+func Sources() []Source {
+	registry.mu.RLock()
+	var o = make([]Source, 0, len(registry.sources))
+	for _, s := range registry.sources {
+		o = append(o, s)
+	}
+	registry.mu.RUnlock()
+
+	slices.SortFunc(o, func(a, b Source) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return o
+}
+
+// NewReader initialises the client to read the data from the registered source.
+func NewReader(name string, cfg Config) (storage.Reader, error) {
+	var (
+		r   storage.Reader
+		err error
+	)
+	switch s, ok := Lookup(name); ok {
+	case true:
+		r = s.New(cfg)
+	case false:
+		err = fmt.Errorf("data source %q is unknown", name)
+	}
+	return r, err
+}
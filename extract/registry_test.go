@@ -0,0 +1,84 @@
+package extract
+
+import (
+	"cigarsdb/storage"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReader struct {
+	HTTPClient HTTPClient
+}
+
+func (m mockReader) Read(_ context.Context, _ string) (storage.Record, error) {
+	return storage.Record{}, nil
+}
+
+func (m mockReader) ReadBulk(_ context.Context, _, _ uint) ([]storage.Record, uint, error) {
+	return nil, 0, nil
+}
+
+func TestRegister(t *testing.T) {
+	Register(Source{
+		Name:    "registry-test-b",
+		BaseURL: "https://b.example.com",
+		New: func(cfg Config) storage.Reader {
+			return mockReader{HTTPClient: cfg.HTTPClient}
+		},
+	})
+	Register(Source{
+		Name: "registry-test-a",
+		New: func(cfg Config) storage.Reader {
+			return mockReader{HTTPClient: cfg.HTTPClient}
+		},
+	})
+
+	t.Run("lookup registered source", func(t *testing.T) {
+		got, ok := Lookup("registry-test-b")
+		assert.True(t, ok)
+		assert.Equal(t, "https://b.example.com", got.BaseURL)
+	})
+
+	t.Run("sources sorted by name", func(t *testing.T) {
+		var got []string
+		for _, s := range Sources() {
+			got = append(got, s.Name)
+		}
+		assert.IsIncreasing(t, got)
+	})
+
+	t.Run("new reader from registered source", func(t *testing.T) {
+		c := MockHTTP{}
+		got, err := NewReader("registry-test-a", Config{HTTPClient: c})
+		assert.NoError(t, err)
+		assert.Equal(t, mockReader{HTTPClient: c}, got)
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		got, err := NewReader("registry-test-unknown", Config{})
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("panics on duplicate", func(t *testing.T) {
+		assert.Panics(t, func() {
+			Register(Source{Name: "registry-test-a", New: func(Config) storage.Reader { return mockReader{} }})
+		})
+	})
+
+	t.Run("replace registered source", func(t *testing.T) {
+		Replace(Source{Name: "registry-test-a", BaseURL: "https://a.example.com",
+			New: func(Config) storage.Reader { return mockReader{} }})
+		got, ok := Lookup("registry-test-a")
+		assert.True(t, ok)
+		assert.Equal(t, "https://a.example.com", got.BaseURL)
+	})
+
+	t.Run("panics without constructor", func(t *testing.T) {
+		assert.Panics(t, func() {
+			Register(Source{Name: "registry-test-c"})
+		})
+	})
+}
@@ -0,0 +1,192 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy defines when and how long the failed requests are retried.
+type RetryPolicy struct {
+	// MaxRetries the max number of retries of the request, i.e., the request is sent MaxRetries+1 times at most.
+	MaxRetries int
+	// InitialDelay the delay before the first retry.
+	InitialDelay time.Duration
+	// Multiplier the growth factor of the delay between the retries, it defaults to 2.
+	Multiplier float64
+	// MaxDelay the max delay between the retries, including the one requested by the Retry-After header.
+	// The delay is not capped if zero.
+	MaxDelay time.Duration
+	// Jitter the randomised fraction of the delay from 0 to 1 which spreads the retries of the concurrent requests.
+	Jitter float64
+	// HostBudget the max number of retries sent to a host during the client's lifetime, not limited if zero.
+	HostBudget int
+}
+
+// RetryClient wraps the HTTPClient to retry the requests which failed because of the network errors,
+// or the statuses 408, 429, 500, 502, 503 and 504.
+// The delay between the retries grows exponentially, unless the server defines it using the Retry-After header.
+type RetryClient struct {
+	HTTPClient HTTPClient
+	Policy     RetryPolicy
+	// Logs the logger to report every retry, the retries are not logged if nil.
+	Logs *slog.Logger
+
+	mu    *sync.Mutex
+	spent map[string]int
+	rand  func() float64
+}
+
+// NewRetryClient initialises the client which retries the requests sent by c according to the policy.
+func NewRetryClient(c HTTPClient, p RetryPolicy, logs *slog.Logger) *RetryClient {
+	if p.Multiplier <= 0 {
+		p.Multiplier = 2
+	}
+	p.Jitter = min(max(p.Jitter, 0), 1)
+	return &RetryClient{
+		HTTPClient: c,
+		Policy:     p,
+		Logs:       logs,
+		mu:         new(sync.Mutex),
+		spent:      make(map[string]int),
+		rand:       rand.Float64,
+	}
+}
+
+func (c *RetryClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+func (c *RetryClient) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.HTTPClient.Do(req)
+		if !retryable(req.Context(), resp, err) || !rewind(req) {
+			return resp, err
+		}
+		if attempt == c.Policy.MaxRetries || !c.spend(req.URL.Host) {
+			if err != nil {
+				err = fmt.Errorf("gave up after %d attempts: %w", attempt+1, err)
+			}
+			return resp, err
+		}
+
+		delay := c.delay(attempt, resp)
+		attrs := []any{
+			slog.String("url", req.URL.String()),
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", delay),
+		}
+		switch err == nil {
+		case true:
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		case false:
+			attrs = append(attrs, slog.Any("error", err))
+		}
+		if c.Logs != nil {
+			c.Logs.Info("retry", attrs...)
+		}
+
+		if err = sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		var blocked *BlockedError
+		return ctx.Err() == nil && !errors.As(err, &blocked) && !errors.Is(err, ErrCacheMiss)
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// rewind resets the request's body to send it again, the request without the body is always rewound.
+func rewind(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+	if req.GetBody == nil {
+		return false
+	}
+	body, err := req.GetBody()
+	if err == nil {
+		req.Body = body
+	}
+	return err == nil
+}
+
+// spend takes one retry from the host's budget.
+func (c *RetryClient) spend(host string) bool {
+	if c.Policy.HostBudget <= 0 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.spent[host] >= c.Policy.HostBudget {
+		return false
+	}
+	c.spent[host]++
+	return true
+}
+
+// delay defines the period before the next attempt.
+// The delay requested by the Retry-After header is preferred, otherwise the delay grows exponentially,
+// and its fraction defined by the policy's Jitter is randomised. Both are capped by the policy's MaxDelay.
+func (c *RetryClient) delay(attempt int, resp *http.Response) time.Duration {
+	var (
+		o  time.Duration
+		ok bool
+	)
+	if resp != nil {
+		o, ok = RetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	if !ok {
+		d := float64(c.Policy.InitialDelay)
+		for range attempt {
+			d *= c.Policy.Multiplier
+		}
+		if c.Policy.MaxDelay > 0 {
+			d = min(d, float64(c.Policy.MaxDelay))
+		}
+		o = time.Duration(d * (1 - c.Policy.Jitter*c.rand()))
+	}
+	if c.Policy.MaxDelay > 0 {
+		o = min(o, c.Policy.MaxDelay)
+	}
+	return o
+}
+
+// RetryAfter parses the value of the Retry-After header defined either as the number of seconds, or as the http date.
+// See https://www.rfc-editor.org/rfc/rfc9110#field.retry-after.
+func RetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(sec, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,149 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryClient(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		switch r.URL.Path {
+		case "/flaky":
+			if n%3 != 0 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	newClient := func(p RetryPolicy) *RetryClient {
+		return NewRetryClient(http.DefaultClient, p, nil)
+	}
+
+	t.Run("5xx is retried until success", func(t *testing.T) {
+		requests.Store(0)
+		resp, err := newClient(RetryPolicy{MaxRetries: 3, InitialDelay: time.Hour}).Get(srv.URL + "/flaky")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("4xx is not retried", func(t *testing.T) {
+		requests.Store(0)
+		resp, err := newClient(RetryPolicy{MaxRetries: 3}).Get(srv.URL + "/missing")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("the last response is returned when retries are exhausted", func(t *testing.T) {
+		requests.Store(0)
+		resp, err := newClient(RetryPolicy{MaxRetries: 2}).Get(srv.URL + "/down")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("host budget is shared by requests", func(t *testing.T) {
+		requests.Store(0)
+		c := newClient(RetryPolicy{MaxRetries: 5, HostBudget: 3})
+		for range 2 {
+			_, _ = c.Get(srv.URL + "/down")
+		}
+		assert.Equal(t, int32(5), requests.Load())
+	})
+
+	t.Run("network error is retried", func(t *testing.T) {
+		var attempts atomic.Int32
+		c := NewRetryClient(&countingHTTP{cnt: &attempts, err: errors.New("connection reset")},
+			RetryPolicy{MaxRetries: 2}, nil)
+		_, err := c.Get(srv.URL)
+		assert.ErrorContains(t, err, "gave up after 3 attempts")
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("blocked request is not retried", func(t *testing.T) {
+		var attempts atomic.Int32
+		c := NewRetryClient(&countingHTTP{cnt: &attempts, err: &DisallowedError{URL: srv.URL}},
+			RetryPolicy{MaxRetries: 2}, nil)
+		_, err := c.Get(srv.URL)
+		assert.ErrorAs(t, err, new(*DisallowedError))
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("sleep is cancelled with the context", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/down", nil)
+		start := time.Now()
+		_, err := newClient(RetryPolicy{MaxRetries: 1, InitialDelay: time.Hour}).Do(req)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+type countingHTTP struct {
+	cnt *atomic.Int32
+	err error
+}
+
+func (m *countingHTTP) Get(string) (*http.Response, error) {
+	m.cnt.Add(1)
+	return nil, m.err
+}
+
+func (m *countingHTTP) Do(*http.Request) (*http.Response, error) {
+	m.cnt.Add(1)
+	return nil, m.err
+}
+
+func TestRetryClient_delay(t *testing.T) {
+	c := NewRetryClient(nil, RetryPolicy{
+		InitialDelay: time.Second,
+		MaxDelay:     10 * time.Second,
+		Jitter:       0.5,
+	}, nil)
+	c.rand = func() float64 { return 1 }
+
+	tests := map[string]struct {
+		attempt    int
+		retryAfter string
+		want       time.Duration
+	}{
+		"first retry":          {attempt: 0, want: 500 * time.Millisecond},
+		"exponential growth":   {attempt: 2, want: 2 * time.Second},
+		"capped":               {attempt: 10, want: 5 * time.Second},
+		"retry-after":          {attempt: 0, retryAfter: "3", want: 3 * time.Second},
+		"retry-after capped":   {attempt: 0, retryAfter: "3600", want: 10 * time.Second},
+		"invalid retry-after":  {attempt: 1, retryAfter: "foo", want: time.Second},
+		"retry-after as date":  {attempt: 0, retryAfter: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), want: 10 * time.Second},
+		"retry-after negative": {attempt: 0, retryAfter: "-1", want: 0},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+			assert.Equal(t, tt.want, c.delay(tt.attempt, resp))
+		})
+	}
+}
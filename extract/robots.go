@@ -0,0 +1,293 @@
+package extract
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DisallowedError defines the error returned when robots.txt disallows fetching the URL.
+// It unwraps to the BlockedError.
+type DisallowedError struct {
+	URL       string
+	UserAgent string
+}
+
+func (e *DisallowedError) Error() string {
+	return fmt.Sprintf("robots.txt disallows %s for the user agent %s", e.URL, e.UserAgent)
+}
+
+func (e *DisallowedError) Unwrap() error {
+	return &BlockedError{URL: e.URL, Reason: "disallowed by robots.txt"}
+}
+
+// robotsTTL defines how long the robots.txt is cached, see https://www.rfc-editor.org/rfc/rfc9309#section-2.4.
+const robotsTTL = 24 * time.Hour
+
+// RobotsClient wraps the HTTPClient to follow the robots.txt rules of every host.
+// The robots.txt is fetched using the wrapped client and cached per host.
+// The requests disallowed by the rules are refused with the DisallowedError.
+// The requests to the host are spaced by its Crawl-delay.
+// The robots.txt which could not be fetched, e.g., because of the status 5xx, is not cached,
+// the request fails with the error which the RetryClient wrapping this client retries, i.e., the robots.txt is
+// fetched again by every attempt.
+type RobotsClient struct {
+	HTTPClient HTTPClient
+	// UserAgent the value of the User-Agent header set to every request.
+	// Its product token, i.e., the part before "/", is used to select the robots.txt group of rules.
+	UserAgent string
+
+	mu    *sync.Mutex
+	hosts map[string]*robotsHost
+}
+
+// NewRobotsClient initialises the client which follows the robots.txt rules for the given user agent.
+func NewRobotsClient(c HTTPClient, userAgent string) *RobotsClient {
+	return &RobotsClient{
+		HTTPClient: c,
+		UserAgent:  userAgent,
+		mu:         new(sync.Mutex),
+		hosts:      make(map[string]*robotsHost),
+	}
+}
+
+func (c *RobotsClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+func (c *RobotsClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if c.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	h := c.host(req.URL)
+	rules, err := h.robots(req.Context(), c)
+	if err != nil {
+		return nil, fmt.Errorf("could not read robots.txt for %s: %w", req.URL.Host, err)
+	}
+	if !rules.Allowed(req.URL.RequestURI()) {
+		return nil, &DisallowedError{URL: req.URL.String(), UserAgent: c.UserAgent}
+	}
+	if err = h.wait(req.Context(), rules.CrawlDelay); err != nil {
+		return nil, err
+	}
+	return c.HTTPClient.Do(req)
+}
+
+func (c *RobotsClient) host(u *url.URL) *robotsHost {
+	key := u.Scheme + "://" + u.Host
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.hosts[key]
+	if !ok {
+		h = &robotsHost{root: key, mu: new(sync.Mutex)}
+		c.hosts[key] = h
+	}
+	return h
+}
+
+type robotsHost struct {
+	root string
+
+	mu        *sync.Mutex
+	rules     *RobotsRules
+	fetchedAt time.Time
+	next      time.Time
+}
+
+func (h *robotsHost) robots(ctx context.Context, c *RobotsClient) (*RobotsRules, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.rules != nil && time.Since(h.fetchedAt) < robotsTTL {
+		return h.rules, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.root+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// https://www.rfc-editor.org/rfc/rfc9309#section-2.3.1
+	var rules *RobotsRules
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		rules = ParseRobots(resp.Body, c.UserAgent)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests:
+		rules = &RobotsRules{}
+	default:
+		// the server is unreachable, the rules are read again by the next request
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	h.rules = rules
+	h.fetchedAt = time.Now()
+	return rules, nil
+}
+
+// wait blocks until the crawl delay since the previous request to the host passes.
+func (h *robotsHost) wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	h.mu.Lock()
+	now := time.Now()
+	start := now
+	if h.next.After(now) {
+		start = h.next
+	}
+	h.next = start.Add(delay)
+	h.mu.Unlock()
+	return sleep(ctx, start.Sub(now))
+}
+
+// RobotsRules defines the robots.txt rules applicable to the user agent.
+type RobotsRules struct {
+	// CrawlDelay the min delay between the requests.
+	CrawlDelay time.Duration
+	rules      []robotsRule
+}
+
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// Allowed checks if the path is allowed to be fetched.
+// The most specific, i.e., the longest matching rule wins, the allow rule wins the tie.
+func (r *RobotsRules) Allowed(path string) bool {
+	var (
+		allowed = true
+		longest = -1
+	)
+	for _, rule := range r.rules {
+		if matchRobotsPattern(rule.pattern, path) {
+			l := len(rule.pattern)
+			if l > longest || (l == longest && rule.allow) {
+				longest = l
+				allowed = rule.allow
+			}
+		}
+	}
+	return allowed
+}
+
+// matchRobotsPattern matches the path against the rule's pattern,
+// where "*" matches any sequence of characters and the trailing "$" anchors the end of the path.
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	path = path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 && anchored {
+			return strings.HasSuffix(path, part)
+		}
+		idx := strings.Index(path, part)
+		if idx < 0 {
+			return false
+		}
+		path = path[idx+len(part):]
+	}
+	return !anchored || path == ""
+}
+
+// ParseRobots reads the rules of the group which matches the user agent's product token.
+// The rules of the group "*" are used if no group matches the user agent.
+func ParseRobots(v io.Reader, userAgent string) *RobotsRules {
+	token := strings.ToLower(strings.TrimSpace(strings.SplitN(userAgent, "/", 2)[0]))
+
+	var (
+		specific, fallback RobotsRules
+		foundSpecific      bool
+		// agents of the current group
+		agents []string
+		// indicates that the group's rules started, i.e., the next user-agent line starts the new group
+		inRules bool
+	)
+
+	scanner := bufio.NewScanner(v)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		k, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		k = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(k, "\ufeff")))
+		val = strings.TrimSpace(val)
+
+		if k == "user-agent" {
+			if inRules {
+				agents = nil
+				inRules = false
+			}
+			agent := strings.ToLower(val)
+			foundSpecific = foundSpecific || (token != "" && agent == token)
+			agents = append(agents, agent)
+			continue
+		}
+
+		var targets []*RobotsRules
+		for _, agent := range agents {
+			switch {
+			case token != "" && agent == token:
+				targets = append(targets, &specific)
+			case agent == "*":
+				targets = append(targets, &fallback)
+			}
+		}
+
+		switch k {
+		case "allow", "disallow":
+			inRules = true
+			if val == "" {
+				// empty disallow allows everything
+				continue
+			}
+			for _, t := range targets {
+				t.rules = append(t.rules, robotsRule{pattern: val, allow: k == "allow"})
+			}
+
+		case "crawl-delay":
+			inRules = true
+			if sec, err := strconv.ParseFloat(val, 64); err == nil && sec > 0 {
+				for _, t := range targets {
+					t.CrawlDelay = time.Duration(sec * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	if foundSpecific {
+		return &specific
+	}
+	return &fallback
+}
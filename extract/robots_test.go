@@ -0,0 +1,173 @@
+package extract
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const robotsTxt = `# comment
+User-agent: *
+Disallow: /checkout/
+Disallow: /*?sort=
+Allow: /checkout/help$
+Crawl-delay: 2
+
+User-agent: googlebot
+User-agent: cigarsdb
+Disallow: /private
+Allow: /private/public
+Crawl-delay: 0.05
+
+Sitemap: https://foo.bar/sitemap.xml
+`
+
+func TestParseRobots(t *testing.T) {
+	t.Run("group of the user agent", func(t *testing.T) {
+		got := ParseRobots(strings.NewReader(robotsTxt), "CigarsDB/1.0 (+https://foo.bar)")
+		assert.Equal(t, 50*time.Millisecond, got.CrawlDelay)
+		tests := map[string]bool{
+			"/":                    true,
+			"/checkout/":           true,
+			"/private":             false,
+			"/private/foo":         false,
+			"/private/public/foo":  true,
+			"/zigarren?sort=price": true,
+		}
+		for path, want := range tests {
+			assert.Equalf(t, want, got.Allowed(path), "path %s", path)
+		}
+	})
+
+	t.Run("fallback group", func(t *testing.T) {
+		got := ParseRobots(strings.NewReader(robotsTxt), "foo")
+		assert.Equal(t, 2*time.Second, got.CrawlDelay)
+		tests := map[string]bool{
+			"/":                    true,
+			"/private":             true,
+			"/checkout/":           false,
+			"/checkout/help":       true,
+			"/checkout/help/foo":   false,
+			"/zigarren?sort=price": false,
+			"/zigarren?page=2":     true,
+		}
+		for path, want := range tests {
+			assert.Equalf(t, want, got.Allowed(path), "path %s", path)
+		}
+	})
+
+	t.Run("empty disallow allows everything", func(t *testing.T) {
+		got := ParseRobots(strings.NewReader("User-agent: *\nDisallow:\n"), "foo")
+		assert.True(t, got.Allowed("/foo"))
+	})
+}
+
+func TestRobotsClient(t *testing.T) {
+	var robotsRequests atomic.Int32
+	var gotUserAgent atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			robotsRequests.Add(1)
+			_, _ = w.Write([]byte(robotsTxt))
+		default:
+			gotUserAgent.Store(r.Header.Get("User-Agent"))
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewRobotsClient(http.DefaultClient, "cigarsdb/test")
+
+	t.Run("allowed", func(t *testing.T) {
+		resp, err := c.Get(srv.URL + "/private/public/foo")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "cigarsdb/test", gotUserAgent.Load())
+	})
+
+	t.Run("disallowed", func(t *testing.T) {
+		_, err := c.Get(srv.URL + "/private/foo")
+		var want *DisallowedError
+		assert.True(t, errors.As(err, &want))
+		assert.Equal(t, srv.URL+"/private/foo", want.URL)
+	})
+
+	t.Run("crawl delay", func(t *testing.T) {
+		start := time.Now()
+		for range 3 {
+			_, err := c.Get(srv.URL + "/foo")
+			assert.NoError(t, err)
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	assert.Equal(t, int32(1), robotsRequests.Load())
+}
+
+func TestRobotsClient_missingRobots(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewRobotsClient(http.DefaultClient, "cigarsdb/test")
+	_, err := c.Get(srv.URL + "/private/foo")
+	assert.NoError(t, err)
+}
+
+func TestRobotsClient_unavailableRobots(t *testing.T) {
+	var robotsRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" && robotsRequests.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("error is not cached", func(t *testing.T) {
+		robotsRequests.Store(0)
+		c := NewRobotsClient(http.DefaultClient, "cigarsdb/test")
+		_, err := c.Get(srv.URL + "/foo")
+		var blocked *BlockedError
+		assert.Error(t, err)
+		assert.False(t, errors.As(err, &blocked))
+
+		_, err = c.Get(srv.URL + "/foo")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), robotsRequests.Load())
+	})
+
+	t.Run("robots.txt is retried", func(t *testing.T) {
+		robotsRequests.Store(0)
+		c := NewRetryClient(NewRobotsClient(http.DefaultClient, "cigarsdb/test"), RetryPolicy{MaxRetries: 1}, nil)
+		resp, err := c.Get(srv.URL + "/foo")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(2), robotsRequests.Load())
+	})
+
+	t.Run("robots.txt is fetched once per attempt", func(t *testing.T) {
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				requests.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer srv.Close()
+
+		c := NewRetryClient(NewRobotsClient(http.DefaultClient, "cigarsdb/test"), RetryPolicy{MaxRetries: 2}, nil)
+		_, err := c.Get(srv.URL + "/foo")
+		assert.Error(t, err)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+}
@@ -0,0 +1,515 @@
+package extract
+
+import (
+	"bytes"
+	"cigarsdb/htmlfilter"
+	"cigarsdb/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Product defines the schema.org Product embedded in the page as JSON-LD, or microdata.
+type Product struct {
+	Name   string  `json:"name"`
+	Brand  string  `json:"brand,omitempty"`
+	GTIN   string  `json:"gtin,omitempty"`
+	SKU    string  `json:"sku,omitempty"`
+	Offers []Offer `json:"offers,omitempty"`
+	// Selector the selector of the structured data the Product was read from, e.g., script[type="application/ld+json"].
+	Selector string `json:"selector,omitempty"`
+}
+
+// the selectors of the structured data reported as the provenance of the values filled by Product.Apply
+const (
+	provenanceJSONLD    = `script[type="application/ld+json"]`
+	provenanceMicrodata = `[itemscope][itemtype="https://schema.org/Product"]`
+)
+
+// Offer defines the schema.org Offer of the Product.
+type Offer struct {
+	Price float64 `json:"price"`
+	// Currency ISO 4217 code of the price's currency, e.g., EUR.
+	Currency string `json:"currency,omitempty"`
+	// Availability the schema.org ItemAvailability without the vocabulary's prefix, e.g., InStock.
+	Availability string `json:"availability,omitempty"`
+	// Quantity the eligible quantity of the offer, e.g., 20 for the box of 20 cigars, zero if it is not known.
+	Quantity float64 `json:"quantity,omitempty"`
+}
+
+// UnitPrice returns the price of a single cigar, i.e., the price divided by the offer's quantity if it is known.
+func (o Offer) UnitPrice() float64 {
+	if o.Quantity > 1 {
+		return o.Price / o.Quantity
+	}
+	return o.Price
+}
+
+// Mismatch defines the record's value which contradicts the structured data of the page.
+type Mismatch struct {
+	URL            string `json:"url"`
+	Field          string `json:"field"`
+	Record         string `json:"record"`
+	StructuredData string `json:"structuredData"`
+}
+
+// Apply fills the record's Name, Brand and Price missing in the record, and reports the mismatches
+// of the values found in both. The offers' prices are compared as the prices of a single cigar, see Offer.UnitPrice.
+// The price is taken from the cheapest offer in Euro, which is the single cigar if the page offers several packages
+// without their quantities, the record's price matches the structured data if any offer has it.
+// The provenance of the filled values is set if the record's provenance is captured.
+func (p Product) Apply(r *storage.Record) []Mismatch {
+	var o []Mismatch
+	check := func(field string, v *string, want string) {
+		switch {
+		case want == "":
+		case *v == "":
+			*v = want
+			p.setProvenance(r, field, want)
+		case !strings.EqualFold(strings.Join(strings.Fields(*v), " "), strings.Join(strings.Fields(want), " ")):
+			o = append(o, Mismatch{URL: r.URL, Field: field, Record: *v, StructuredData: want})
+		}
+	}
+	check("Name", &r.Name, p.Name)
+	check("Brand", &r.Brand, p.Brand)
+
+	var (
+		prices []string
+		lowest = math.Inf(1)
+		found  bool
+	)
+	for _, offer := range p.Offers {
+		if !strings.EqualFold(offer.Currency, "EUR") || offer.Price <= 0 {
+			continue
+		}
+		price := math.Round(offer.UnitPrice()*100) / 100
+		lowest = min(lowest, price)
+		found = found || math.Abs(price-r.Price) < 0.01
+		prices = append(prices, strconv.FormatFloat(price, 'f', -1, 64))
+	}
+	switch {
+	case len(prices) == 0:
+	case r.Price == 0:
+		r.Price = lowest
+		p.setProvenance(r, "Price", strconv.FormatFloat(lowest, 'f', -1, 64))
+	case !found:
+		o = append(o, Mismatch{URL: r.URL, Field: "Price", Record: strconv.FormatFloat(r.Price, 'f', -1, 64),
+			StructuredData: strings.Join(prices, ",")})
+	}
+	return o
+}
+
+// setProvenance sets the provenance of the field filled from the structured data. The source, the extractor's version
+// and the fetch time are the same as of the other fields read from the record's page.
+func (p Product) setProvenance(r *storage.Record, field, raw string) {
+	if r.Provenance == nil {
+		return
+	}
+	var v storage.Provenance
+	for _, el := range r.Provenance {
+		if el.URL == r.URL {
+			v = el
+			break
+		}
+	}
+	r.SetProvenance(field, storage.Provenance{Source: v.Source, URL: r.URL, Selector: p.Selector, Raw: raw,
+		ExtractorVersion: v.ExtractorVersion, FetchedAt: v.FetchedAt})
+}
+
+var (
+	selectorJSONLD    = htmlfilter.MustCompile(`script[type="application/ld+json" i]`)
+	selectorMicrodata = htmlfilter.MustCompile("[itemscope][itemtype]")
+)
+
+// ReadProducts returns the schema.org Products found in the JSON-LD blocks and the microdata of the page.
+// The malformed JSON-LD blocks are skipped.
+func ReadProducts(n htmlfilter.Node) []Product {
+	var o []Product
+	for script := range selectorJSONLD.All(n) {
+		var s strings.Builder
+		for c := range script.ChildNodes() {
+			if c.Type == html.TextNode {
+				s.WriteString(c.Data)
+			}
+		}
+		var v any
+		if err := json.Unmarshal([]byte(s.String()), &v); err == nil {
+			o = appendProducts(o, v, provenanceJSONLD)
+		}
+	}
+	for item := range selectorMicrodata.All(n) {
+		if t, _ := item.Attr("itemtype"); schemaType(t) == "Product" {
+			if _, nested := item.Attr("itemprop"); !nested {
+				o = appendProducts(o, readMicrodataItem(item.Node), provenanceMicrodata)
+			}
+		}
+	}
+	return o
+}
+
+// appendProducts walks the JSON-LD value and appends the Products found in it, e.g., in @graph, or mainEntity.
+func appendProducts(o []Product, v any, selector string) []Product {
+	switch v := v.(type) {
+	case []any:
+		for _, el := range v {
+			o = appendProducts(o, el, selector)
+		}
+	case map[string]any:
+		if hasSchemaType(v, "Product") {
+			p := newProduct(v)
+			p.Selector = selector
+			return append(o, p)
+		}
+		for _, el := range v {
+			o = appendProducts(o, el, selector)
+		}
+	}
+	return o
+}
+
+func newProduct(v map[string]any) Product {
+	o := Product{
+		Name: jsonString(v["name"]),
+		SKU:  jsonString(v["sku"]),
+	}
+	switch b := v["brand"].(type) {
+	case map[string]any:
+		o.Brand = jsonString(b["name"])
+	case []any:
+		if len(b) > 0 {
+			if m, ok := b[0].(map[string]any); ok {
+				o.Brand = jsonString(m["name"])
+			} else {
+				o.Brand = jsonString(b[0])
+			}
+		}
+	default:
+		o.Brand = jsonString(b)
+	}
+	for _, k := range []string{"gtin", "gtin13", "gtin14", "gtin12", "gtin8"} {
+		if o.GTIN = jsonString(v[k]); o.GTIN != "" {
+			break
+		}
+	}
+	o.Offers = appendOffers(nil, v["offers"])
+	return o
+}
+
+func appendOffers(o []Offer, v any) []Offer {
+	switch v := v.(type) {
+	case []any:
+		for _, el := range v {
+			o = appendOffers(o, el)
+		}
+	case map[string]any:
+		if nested, ok := v["offers"]; ok && hasSchemaType(v, "AggregateOffer") {
+			return appendOffers(o, nested)
+		}
+		offer := Offer{
+			Currency:     jsonString(v["priceCurrency"]),
+			Availability: schemaType(jsonString(v["availability"])),
+			Quantity:     jsonQuantity(v["eligibleQuantity"]),
+		}
+		var ok bool
+		if offer.Price, ok = jsonNumber(v["price"]); !ok {
+			offer.Price, ok = jsonNumber(v["lowPrice"])
+		}
+		if ok {
+			o = append(o, offer)
+		}
+	}
+	return o
+}
+
+func hasSchemaType(v map[string]any, t string) bool {
+	switch vv := v["@type"].(type) {
+	case string:
+		return schemaType(vv) == t
+	case []any:
+		for _, el := range vv {
+			if s, ok := el.(string); ok && schemaType(s) == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaType trims the vocabulary's prefix, e.g., https://schema.org/InStock -> InStock.
+func schemaType(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexAny(s, "/:"); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
+
+func jsonString(v any) string {
+	var o string
+	switch v := v.(type) {
+	case string:
+		o = strings.TrimSpace(v)
+	case float64:
+		o = strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		if len(v) > 0 {
+			o = jsonString(v[0])
+		}
+	}
+	return o
+}
+
+func jsonNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		o, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v), ",", "."), 64)
+		return o, err == nil
+	case []any:
+		if len(v) > 0 {
+			return jsonNumber(v[0])
+		}
+	}
+	return 0, false
+}
+
+// jsonQuantity returns the value of the schema.org QuantitativeValue, or the number.
+func jsonQuantity(v any) float64 {
+	if m, ok := v.(map[string]any); ok {
+		v = m["value"]
+	}
+	o, _ := jsonNumber(v)
+	return o
+}
+
+// readMicrodataItem converts the microdata item to the JSON-LD equivalent, the nested items are converted to objects.
+func readMicrodataItem(n *html.Node) map[string]any {
+	t, _ := htmlfilter.Node{Node: n}.Attr("itemtype")
+	var o = map[string]any{"@type": schemaType(t)}
+	for c := range n.ChildNodes() {
+		readMicrodataProperties(c, o)
+	}
+	return o
+}
+
+func readMicrodataProperties(n *html.Node, o map[string]any) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	nn := htmlfilter.Node{Node: n}
+	prop, isProp := nn.Attr("itemprop")
+	_, isItem := nn.Attr("itemscope")
+	if isProp {
+		var v any
+		switch isItem {
+		case true:
+			v = readMicrodataItem(n)
+		case false:
+			v = microdataValue(nn)
+		}
+		for _, k := range strings.Fields(prop) {
+			switch old := o[k].(type) {
+			case nil:
+				o[k] = v
+			case []any:
+				o[k] = append(old, v)
+			default:
+				o[k] = []any{old, v}
+			}
+		}
+	}
+	if isItem {
+		// the properties of the nested item belong to it
+		return
+	}
+	for c := range n.ChildNodes() {
+		readMicrodataProperties(c, o)
+	}
+}
+
+func microdataValue(n htmlfilter.Node) string {
+	var k string
+	switch n.DataAtom {
+	case atom.Meta:
+		k = "content"
+	case atom.A, atom.Link, atom.Area:
+		k = "href"
+	case atom.Img, atom.Audio, atom.Video, atom.Source, atom.Iframe, atom.Embed:
+		k = "src"
+	case atom.Data, atom.Meter:
+		k = "value"
+	case atom.Time:
+		k = "datetime"
+	case atom.Object:
+		k = "data"
+	}
+	if k == "" {
+		// the content attribute is used by the shops on any element, e.g., span itemprop="price" content="8.90"
+		k = "content"
+	}
+	if v, ok := n.Attr(k); ok {
+		return strings.TrimSpace(v)
+	}
+	return n.Text()
+}
+
+// StructuredData fills, or cross-checks the records' Name, Brand and Price using the schema.org Products embedded
+// in the pages, see Product.Apply. The Client wrapper reads the Products from the fetched pages,
+// the Reader wrapper applies them to the records read from the page with the record's URL.
+// The Products which were not applied, e.g., of the failed pages, are discarded once no read is in progress.
+// The mismatches are logged and collected.
+type StructuredData struct {
+	Logs *slog.Logger
+
+	mu         *sync.Mutex
+	products   map[string][]Product
+	reads      int
+	mismatches []Mismatch
+}
+
+// NewStructuredData initialises StructuredData.
+func NewStructuredData(logs *slog.Logger) *StructuredData {
+	return &StructuredData{Logs: logs, mu: new(sync.Mutex), products: make(map[string][]Product)}
+}
+
+// Client wraps the HTTPClient to read the Products from the successful responses.
+func (s *StructuredData) Client(c HTTPClient) HTTPClient {
+	return structuredDataClient{HTTPClient: c, data: s}
+}
+
+// Reader wraps the source's client to apply the Products to the records it reads.
+func (s *StructuredData) Reader(r storage.Reader) storage.Reader {
+	return structuredDataReader{Reader: r, data: s}
+}
+
+// Mismatches returns the mismatches found so far.
+func (s *StructuredData) Mismatches() []Mismatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mismatch(nil), s.mismatches...)
+}
+
+// begin marks the start of the read.
+func (s *StructuredData) begin() {
+	s.mu.Lock()
+	s.reads++
+	s.mu.Unlock()
+}
+
+// end marks the end of the read, the products are discarded if no other read is in progress.
+func (s *StructuredData) end() {
+	s.mu.Lock()
+	if s.reads--; s.reads == 0 {
+		clear(s.products)
+	}
+	s.mu.Unlock()
+}
+
+func (s *StructuredData) apply(r *storage.Record) {
+	// the record without URL is discarded by the source, e.g., a sampler
+	if r.URL == "" {
+		return
+	}
+	s.mu.Lock()
+	products := s.products[r.URL]
+	delete(s.products, r.URL)
+	s.mu.Unlock()
+	if len(products) == 0 {
+		return
+	}
+
+	// the page's product is the one with the record's name, or the first one
+	p := products[0]
+	for _, el := range products {
+		if strings.EqualFold(el.Name, r.Name) {
+			p = el
+			break
+		}
+	}
+	mismatches := p.Apply(r)
+	for _, m := range mismatches {
+		if s.Logs != nil {
+			s.Logs.Warn("structured data mismatch", slog.String("url", m.URL), slog.String("field", m.Field),
+				slog.String("record", m.Record), slog.String("structured data", m.StructuredData))
+		}
+	}
+	s.mu.Lock()
+	s.mismatches = append(s.mismatches, mismatches...)
+	s.mu.Unlock()
+}
+
+type structuredDataClient struct {
+	HTTPClient HTTPClient
+	data       *StructuredData
+}
+
+func (c structuredDataClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+func (c structuredDataClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp, err
+	}
+
+	url := req.URL.String()
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the response from %s: %w", url, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// the cheap check prevents parsing the pages without the structured data twice
+	if bytes.Contains(body, []byte("schema.org")) {
+		if doc, er := html.Parse(bytes.NewReader(body)); er == nil {
+			if products := ReadProducts(htmlfilter.Node{Node: doc}); len(products) > 0 {
+				c.data.mu.Lock()
+				c.data.products[url] = products
+				c.data.mu.Unlock()
+			}
+		}
+	}
+	return resp, nil
+}
+
+type structuredDataReader struct {
+	storage.Reader
+	data *StructuredData
+}
+
+func (r structuredDataReader) Read(ctx context.Context, id string) (storage.Record, error) {
+	r.data.begin()
+	defer r.data.end()
+	o, err := r.Reader.Read(ctx, id)
+	if err == nil {
+		r.data.apply(&o)
+	}
+	return o, err
+}
+
+func (r structuredDataReader) ReadBulk(ctx context.Context, limit, page uint) ([]storage.Record, uint, error) {
+	r.data.begin()
+	defer r.data.end()
+	o, nextPage, err := r.Reader.ReadBulk(ctx, limit, page)
+	for i := range o {
+		r.data.apply(&o[i])
+	}
+	return o, nextPage, err
+}
@@ -0,0 +1,235 @@
+package extract
+
+import (
+	"bytes"
+	"cigarsdb/htmlfilter"
+	"cigarsdb/storage"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestReadProducts(t *testing.T) {
+	parse := func(t *testing.T, s string) htmlfilter.Node {
+		t.Helper()
+		doc, err := html.Parse(strings.NewReader(s))
+		assert.NoError(t, err)
+		return htmlfilter.Node{Node: doc}
+	}
+
+	t.Run("json-ld of noblego.de", func(t *testing.T) {
+		b, err := os.ReadFile("noblego/testdata/www.noblego.de-diesel-cask-aged-robusto-zigarren-152f095d.html")
+		assert.NoError(t, err)
+		got := ReadProducts(parse(t, string(b)))
+		assert.Equal(t, []Product{
+			{
+				Name:  "Diesel Cask Aged Robusto",
+				Brand: "Diesel",
+				SKU:   "CIG-DIE-005",
+				Offers: []Offer{
+					{Price: 172.66, Currency: "EUR", Availability: "InStock"},
+					{Price: 8.9, Currency: "EUR", Availability: "InStock"},
+				},
+				Selector: provenanceJSONLD,
+			},
+		}, got)
+	})
+
+	t.Run("json-ld graph with aggregate offer", func(t *testing.T) {
+		got := ReadProducts(parse(t, `<script type="application/ld+json">{"@context": "https://schema.org",
+"@graph": [{"@type": "Organization", "name": "Shop"}, {"@type": ["Product"], "name": "No. 2",
+"brand": "Montecristo", "gtin13": "4001234567890", "offers": {"@type": "AggregateOffer", "lowPrice": "24,20",
+"priceCurrency": "EUR"}}]}</script><script type="application/ld+json">{malformed</script>`))
+		assert.Equal(t, []Product{
+			{
+				Name:     "No. 2",
+				Brand:    "Montecristo",
+				GTIN:     "4001234567890",
+				Offers:   []Offer{{Price: 24.2, Currency: "EUR"}},
+				Selector: provenanceJSONLD,
+			},
+		}, got)
+	})
+
+	t.Run("json-ld offer with eligible quantity", func(t *testing.T) {
+		got := ReadProducts(parse(t, `<script type="application/ld+json">{"@context": "https://schema.org",
+"@type": "Product", "name": "Robusto", "offers": [{"price": 172.66, "priceCurrency": "EUR",
+"eligibleQuantity": {"@type": "QuantitativeValue", "value": 20}}, {"price": 8.9, "priceCurrency": "EUR",
+"eligibleQuantity": 1}]}</script>`))
+		assert.Equal(t, []Product{
+			{
+				Name:     "Robusto",
+				Offers:   []Offer{{Price: 172.66, Currency: "EUR", Quantity: 20}, {Price: 8.9, Currency: "EUR", Quantity: 1}},
+				Selector: provenanceJSONLD,
+			},
+		}, got)
+	})
+
+	t.Run("microdata", func(t *testing.T) {
+		got := ReadProducts(parse(t, `<div itemscope itemtype="https://schema.org/Product">
+<h1 itemprop="name">Robusto</h1>
+<div itemprop="brand" itemscope itemtype="https://schema.org/Brand"><span itemprop="name">Diesel</span></div>
+<meta itemprop="gtin8" content="12345670">
+<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+	<span itemprop="price" content="8.90">8,90 €</span><meta itemprop="priceCurrency" content="EUR">
+	<link itemprop="availability" href="https://schema.org/OutOfStock">
+</div></div>`))
+		assert.Equal(t, []Product{
+			{
+				Name:     "Robusto",
+				Brand:    "Diesel",
+				GTIN:     "12345670",
+				Offers:   []Offer{{Price: 8.9, Currency: "EUR", Availability: "OutOfStock"}},
+				Selector: provenanceMicrodata,
+			},
+		}, got)
+	})
+}
+
+func TestProduct_Apply(t *testing.T) {
+	p := Product{
+		Name:   "Diesel Cask Aged Robusto",
+		Brand:  "Diesel",
+		Offers: []Offer{{Price: 172.66, Currency: "EUR"}, {Price: 8.9, Currency: "EUR"}, {Price: 5, Currency: "USD"}},
+	}
+
+	t.Run("fill missing", func(t *testing.T) {
+		r := storage.Record{URL: "foo"}
+		assert.Empty(t, p.Apply(&r))
+		assert.Equal(t, storage.Record{URL: "foo", Name: "Diesel Cask Aged Robusto", Brand: "Diesel", Price: 8.9}, r)
+	})
+
+	t.Run("provenance of the filled values", func(t *testing.T) {
+		p := p
+		p.Selector = provenanceJSONLD
+		fetchedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+		r := storage.Record{URL: "foo", Name: "Robusto", Provenance: map[string]storage.Provenance{
+			"Name": {Source: "bar", URL: "foo", Selector: "h1", Raw: "Robusto", ExtractorVersion: "v1",
+				FetchedAt: fetchedAt},
+		}}
+		assert.NotEmpty(t, p.Apply(&r))
+		assert.Equal(t, map[string]storage.Provenance{
+			"Name": {Source: "bar", URL: "foo", Selector: "h1", Raw: "Robusto", ExtractorVersion: "v1",
+				FetchedAt: fetchedAt},
+			"Brand": {Source: "bar", URL: "foo", Selector: provenanceJSONLD, Raw: "Diesel", ExtractorVersion: "v1",
+				FetchedAt: fetchedAt},
+			"Price": {Source: "bar", URL: "foo", Selector: provenanceJSONLD, Raw: "8.9", ExtractorVersion: "v1",
+				FetchedAt: fetchedAt},
+		}, r.Provenance)
+
+		r = storage.Record{URL: "foo"}
+		p.Apply(&r)
+		assert.Nil(t, r.Provenance)
+	})
+
+	t.Run("cross-check", func(t *testing.T) {
+		r := storage.Record{URL: "foo", Name: "Diesel  cask aged Robusto", Brand: "Dissel", Price: 9.9}
+		assert.Equal(t, []Mismatch{
+			{URL: "foo", Field: "Brand", Record: "Dissel", StructuredData: "Diesel"},
+			{URL: "foo", Field: "Price", Record: "9.9", StructuredData: "172.66,8.9"},
+		}, p.Apply(&r))
+		assert.Equal(t, "Dissel", r.Brand)
+		assert.Equal(t, 9.9, r.Price)
+	})
+
+	t.Run("price of the pack", func(t *testing.T) {
+		p := Product{Offers: []Offer{{Price: 172.6, Currency: "EUR", Quantity: 20}}}
+		r := storage.Record{URL: "foo"}
+		assert.Empty(t, p.Apply(&r))
+		assert.Equal(t, 8.63, r.Price)
+
+		r = storage.Record{URL: "foo", Price: 8.63}
+		assert.Empty(t, p.Apply(&r))
+	})
+}
+
+type mockStructuredReader struct {
+	HTTPClient HTTPClient
+	Name       string
+	// Err fails the read after the page is fetched.
+	Err error
+}
+
+func (m mockStructuredReader) Read(ctx context.Context, id string) (storage.Record, error) {
+	resp, err := Get(ctx, m.HTTPClient, id)
+	if err != nil {
+		return storage.Record{}, err
+	}
+	_ = resp.Body.Close()
+	if m.Err != nil {
+		return storage.Record{}, m.Err
+	}
+	return storage.Record{URL: id, Name: m.Name}, nil
+}
+
+func (m mockStructuredReader) ReadBulk(ctx context.Context, _, _ uint) ([]storage.Record, uint, error) {
+	r, err := m.Read(ctx, "https://foo.com/bar")
+	return []storage.Record{r, {}}, 0, err
+}
+
+func TestStructuredData(t *testing.T) {
+	const page = `<html><head><script type="application/ld+json">{"@context": "https://schema.org",
+"@type": "Product", "name": "Bar", "brand": {"name": "Foo"}, "offers": {"price": 10, "priceCurrency": "EUR"}}
+</script></head><body><h1>Bar</h1></body></html>`
+	newHTTP := func() HTTPClient {
+		return MockHTTP{BodyRoute: map[string]io.ReadCloser{
+			"https://foo.com/bar": io.NopCloser(strings.NewReader(page)),
+		}}
+	}
+
+	t.Run("body is served after reading the products", func(t *testing.T) {
+		s := NewStructuredData(nil)
+		req, _ := http.NewRequest(http.MethodGet, "https://foo.com/bar", nil)
+		resp, err := s.Client(newHTTP()).Do(req)
+		assert.NoError(t, err)
+		b, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, page, string(b))
+	})
+
+	t.Run("missing values are filled", func(t *testing.T) {
+		s := NewStructuredData(nil)
+		r := s.Reader(mockStructuredReader{HTTPClient: s.Client(newHTTP())})
+		got, _, err := r.ReadBulk(context.TODO(), 0, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.Record{{URL: "https://foo.com/bar", Name: "Bar", Brand: "Foo", Price: 10}, {}}, got)
+		assert.Empty(t, s.Mismatches())
+	})
+
+	t.Run("mismatch is reported", func(t *testing.T) {
+		s := NewStructuredData(nil)
+		r := s.Reader(mockStructuredReader{HTTPClient: s.Client(newHTTP()), Name: "Baz"})
+		got, err := r.Read(context.TODO(), "https://foo.com/bar")
+		assert.NoError(t, err)
+		assert.Equal(t, storage.Record{URL: "https://foo.com/bar", Name: "Baz", Brand: "Foo", Price: 10}, got)
+		assert.Equal(t, []Mismatch{
+			{URL: "https://foo.com/bar", Field: "Name", Record: "Baz", StructuredData: "Bar"},
+		}, s.Mismatches())
+	})
+
+	t.Run("products of the failed page are discarded", func(t *testing.T) {
+		s := NewStructuredData(nil)
+		r := s.Reader(mockStructuredReader{HTTPClient: s.Client(newHTTP()), Err: assert.AnError})
+		_, err := r.Read(context.TODO(), "https://foo.com/bar")
+		assert.ErrorIs(t, err, assert.AnError)
+		_, _, err = r.ReadBulk(context.TODO(), 0, 1)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Empty(t, s.products)
+	})
+
+	t.Run("page without structured data", func(t *testing.T) {
+		s := NewStructuredData(nil)
+		c := s.Client(MockHTTP{Body: io.NopCloser(bytes.NewReader([]byte("<html></html>")))})
+		r := s.Reader(mockStructuredReader{HTTPClient: c, Name: "Baz"})
+		got, err := r.Read(context.TODO(), "https://foo.com/bar")
+		assert.NoError(t, err)
+		assert.Equal(t, storage.Record{URL: "https://foo.com/bar", Name: "Baz"}, got)
+	})
+}
@@ -6,10 +6,10 @@ require (
 	github.com/neo4j/neo4j-go-driver/v5 v5.28.0
 	github.com/stretchr/testify v1.10.0
 	golang.org/x/net v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -5,6 +5,7 @@ import (
 	"iter"
 	"slices"
 	"strings"
+	"sync"
 
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
@@ -22,8 +23,14 @@ type Node struct {
 //
 // Class selector: div.class0.class1.
 // Id selector: div#id.
+//
+// The selector is parsed once and cached. It panics if the selector is invalid.
+//
+// Deprecated: the invalid selector is only found when the page is read, use Selector.Find with the package-level
+// selector initialised by MustCompile instead, e.g., li.foo*, is li[class*="foo"].
 func (n Node) Find(selector string) iter.Seq[Node] {
-	atomFilter, attrKeyRef, attrValFilter := readSelector(selector)
+	s := mustReadSelector(selector)
+	atomFilter, attrKeyRef, attrValFilter := s.elementAtom, s.attrKeyRef, s.attrValFilter
 	var parentOfFirstFound *Node
 	for nn := range n.Descendants() {
 		if parentOfFirstFound != nil {
@@ -97,7 +104,33 @@ func idSelector(v string) selectorFn {
 	}
 }
 
-func readSelector(s string) (elementAtom atom.Atom, attrKeyRef string, attrValFilter selectorFn) {
+// legacySelector defines the parsed selector of Find.
+type legacySelector struct {
+	elementAtom   atom.Atom
+	attrKeyRef    string
+	attrValFilter selectorFn
+}
+
+// legacySelectors caches the selectors parsed by Find.
+var legacySelectors sync.Map
+
+func mustReadSelector(s string) legacySelector {
+	if v, ok := legacySelectors.Load(s); ok {
+		return v.(legacySelector)
+	}
+	var (
+		o   legacySelector
+		err error
+	)
+	o.elementAtom, o.attrKeyRef, o.attrValFilter, err = readSelector(s)
+	if err != nil {
+		panic(err)
+	}
+	legacySelectors.Store(s, o)
+	return o
+}
+
+func readSelector(s string) (elementAtom atom.Atom, attrKeyRef string, attrValFilter selectorFn, err error) {
 	idSplit := strings.SplitN(s, "#", 2)
 	classSplit := strings.Split(s, ".")
 	switch {
@@ -113,12 +146,13 @@ func readSelector(s string) (elementAtom atom.Atom, attrKeyRef string, attrValFi
 		elementAtom = atom.Lookup([]byte(s))
 	}
 	if elementAtom == 0 {
-		panic("unsupported selector provided")
+		err = &SelectorError{Selector: s, Msg: "unknown element"}
 	}
-	return elementAtom, attrKeyRef, attrValFilter
+	return elementAtom, attrKeyRef, attrValFilter, err
 }
 
 // InnerHTML equivalent of the js method innerHTML.
+// Use Node.Markdown to store the node's content as the record's details.
 func InnerHTML(s *html.Node) string {
 	var o bytes.Buffer
 	for c := range s.ChildNodes() {
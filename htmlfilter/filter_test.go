@@ -187,7 +187,8 @@ func Test_readSelector(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotElementAtom, gotAttrKeyRef, gotAttrValFilter := readSelector(tt.selector)
+			gotElementAtom, gotAttrKeyRef, gotAttrValFilter, err := readSelector(tt.selector)
+			assert.NoError(t, err)
 			assert.Equal(t, tt.wantElementAtom, gotElementAtom)
 			assert.Equal(t, tt.wantAttrKeyRef, gotAttrKeyRef)
 			for _, v := range tt.attrValMiss {
@@ -200,6 +201,12 @@ func Test_readSelector(t *testing.T) {
 	}
 }
 
+func Test_readSelector_unknownElement(t *testing.T) {
+	_, _, _, err := readSelector("dvi.foo")
+	var want *SelectorError
+	assert.ErrorAs(t, err, &want)
+}
+
 func TestInnerHTML(t *testing.T) {
 	in, err := html.Parse(strings.NewReader(`<div class="0">
 	<div class="1">
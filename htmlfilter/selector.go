@@ -0,0 +1,690 @@
+package htmlfilter
+
+import (
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Selector defines the compiled CSS selector.
+//
+// Supported syntax:
+//   - type, universal, class and id selectors: div, *, .class0.class1, #id;
+//   - attribute selectors: [attr], [attr=val], [attr~=val], [attr|=val], [attr^=val], [attr$=val], [attr*=val],
+//     the value may be quoted, the flag i makes the comparison case-insensitive: [attr=val i];
+//   - pseudo-classes: :first-child, :last-child, :only-child, :first-of-type, :last-of-type, :only-of-type,
+//     :nth-child(an+b), :nth-last-child(an+b), :nth-of-type(an+b), :nth-last-of-type(an+b), :empty, :root,
+//     :not(selector list) and :contains(text) which matches the elements containing the text;
+//   - combinators: descendant (space), child (>), next-sibling (+) and subsequent-sibling (~);
+//   - selector lists: h1, h2.
+type Selector struct {
+	s    string
+	list selectorList
+}
+
+// SelectorError defines the error returned when the CSS selector, or the XPath expression could not be compiled.
+type SelectorError struct {
+	Selector string
+	// Pos the byte offset in the selector where the error was found.
+	Pos int
+	Msg string
+}
+
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("invalid selector %q at position %d: %s", e.Selector, e.Pos, e.Msg)
+}
+
+// compiled caches the selectors compiled by the package.
+var compiled sync.Map
+
+// Compile parses the CSS selector, the result is cached, hence the selector is parsed once.
+func Compile(selector string) (Selector, error) {
+	if v, ok := compiled.Load(selector); ok {
+		return v.(Selector), nil
+	}
+	l, err := parseSelector(selector)
+	if err != nil {
+		return Selector{}, err
+	}
+	o := Selector{s: selector, list: l}
+	compiled.Store(selector, o)
+	return o, nil
+}
+
+// MustCompile is like Compile, but panics if the selector is invalid.
+// It simplifies the initialisation of the package-level selectors, which are compiled on init
+// to fail at start if the selector is invalid.
+func MustCompile(selector string) Selector {
+	o, err := Compile(selector)
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+func (s Selector) String() string {
+	return s.s
+}
+
+// Match reports whether the node matches the selector.
+func (s Selector) Match(n Node) bool {
+	return n.Node != nil && s.list.match(n.Node)
+}
+
+// All returns an iterator over the descendants of n which match the selector in the document order.
+// Like querySelectorAll, the selector is matched against the whole document, i.e., the ancestors of n take part in
+// the matching of the combinators.
+func (s Selector) All(n Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		if n.Node == nil {
+			return
+		}
+		for nn := range n.Descendants() {
+			if s.list.match(nn) && !yield(Node{nn}) {
+				return
+			}
+		}
+	}
+}
+
+// First returns the first descendant of n which matches the selector.
+func (s Selector) First(n Node) (Node, bool) {
+	for nn := range s.All(n) {
+		return nn, true
+	}
+	return Node{}, false
+}
+
+// Find returns an iterator over the elements which match the selector at the level of the first match among the
+// descendants of n, i.e., the first match in the document order and its matching siblings, like Node.Find.
+func (s Selector) Find(n Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		first, ok := s.First(n)
+		if !ok || first.Parent == nil {
+			return
+		}
+		for nn := range first.Parent.ChildNodes() {
+			if s.list.match(nn) && !yield(Node{nn}) {
+				return
+			}
+		}
+	}
+}
+
+// Closest returns n, or its closest ancestor which matches the selector.
+func (s Selector) Closest(n Node) (Node, bool) {
+	for nn := n.Node; nn != nil; nn = nn.Parent {
+		if s.list.match(nn) {
+			return Node{nn}, true
+		}
+	}
+	return Node{}, false
+}
+
+// FindAll returns an iterator over the descendants of n which match the CSS selector in the document order,
+// see Selector.All. It panics if the selector is invalid, use Compile to validate it.
+func (n Node) FindAll(selector string) iter.Seq[Node] {
+	return MustCompile(selector).All(n)
+}
+
+// First returns the first descendant of n which matches the CSS selector, see FindAll.
+func (n Node) First(selector string) (Node, bool) {
+	return MustCompile(selector).First(n)
+}
+
+// Closest returns n, or its closest ancestor which matches the CSS selector, see FindAll.
+func (n Node) Closest(selector string) (Node, bool) {
+	return MustCompile(selector).Closest(n)
+}
+
+// selectorList matches the element if any of its selectors matches it, e.g., h1, h2.
+type selectorList []complexSelector
+
+func (l selectorList) match(n *html.Node) bool {
+	for _, s := range l {
+		if s.match(n) {
+			return true
+		}
+	}
+	return false
+}
+
+type combinator byte
+
+const (
+	descendant        combinator = ' '
+	child             combinator = '>'
+	nextSibling       combinator = '+'
+	subsequentSibling combinator = '~'
+)
+
+// complexSelector defines the compound selectors joined by the combinators, e.g., ul.list > li a.
+type complexSelector struct {
+	compounds []compoundSelector
+	// combinators joins the compounds, i.e., combinators[i] is placed between compounds[i] and compounds[i+1].
+	combinators []combinator
+}
+
+func (s complexSelector) match(n *html.Node) bool {
+	return s.matchAt(n, len(s.compounds)-1)
+}
+
+// matchAt matches the selector's compounds from right to left starting from the compound i.
+func (s complexSelector) matchAt(n *html.Node, i int) bool {
+	if !s.compounds[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	switch s.combinators[i-1] {
+	case descendant:
+		for p := n.Parent; p != nil; p = p.Parent {
+			if s.matchAt(p, i-1) {
+				return true
+			}
+		}
+	case child:
+		return n.Parent != nil && s.matchAt(n.Parent, i-1)
+	case nextSibling:
+		p := prevElement(n)
+		return p != nil && s.matchAt(p, i-1)
+	case subsequentSibling:
+		for p := prevElement(n); p != nil; p = prevElement(p) {
+			if s.matchAt(p, i-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type matcher func(n *html.Node) bool
+
+// compoundSelector defines the conditions of a single element, e.g., a.link[href].
+type compoundSelector struct {
+	// tag the element's name, any element matches if it is empty.
+	tag     string
+	tagAtom atom.Atom
+	conds   []matcher
+}
+
+func (s compoundSelector) match(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	switch {
+	case s.tag == "":
+	case s.tagAtom != 0:
+		if n.DataAtom != s.tagAtom {
+			return false
+		}
+	default:
+		if n.Data != s.tag {
+			return false
+		}
+	}
+	for _, cond := range s.conds {
+		if !cond(n) {
+			return false
+		}
+	}
+	return true
+}
+
+func prevElement(n *html.Node) *html.Node {
+	for p := n.PrevSibling; p != nil; p = p.PrevSibling {
+		if p.Type == html.ElementNode {
+			return p
+		}
+	}
+	return nil
+}
+
+func attrMatcher(key, op, val string, fold bool) matcher {
+	if fold {
+		val = strings.ToLower(val)
+	}
+	return func(n *html.Node) bool {
+		for _, att := range n.Attr {
+			if att.Key != key {
+				continue
+			}
+			v := att.Val
+			if fold {
+				v = strings.ToLower(v)
+			}
+			switch op {
+			case "":
+				return true
+			case "=":
+				return v == val
+			case "~=":
+				for _, f := range strings.Fields(v) {
+					if f == val {
+						return true
+					}
+				}
+				return false
+			case "|=":
+				return v == val || strings.HasPrefix(v, val+"-")
+			case "^=":
+				return val != "" && strings.HasPrefix(v, val)
+			case "$=":
+				return val != "" && strings.HasSuffix(v, val)
+			case "*=":
+				return val != "" && strings.Contains(v, val)
+			}
+		}
+		return false
+	}
+}
+
+// position returns the 1-based position of the element among its sibling elements counted from the start, or from
+// the end. Only the elements of the same type are counted if ofType is true.
+func position(n *html.Node, fromEnd, ofType bool) int {
+	next := func(v *html.Node) *html.Node {
+		if fromEnd {
+			return v.NextSibling
+		}
+		return v.PrevSibling
+	}
+	var o = 1
+	for s := next(n); s != nil; s = next(s) {
+		if s.Type == html.ElementNode && (!ofType || (s.DataAtom == n.DataAtom && s.Data == n.Data)) {
+			o++
+		}
+	}
+	return o
+}
+
+func nthMatcher(a, b int, fromEnd, ofType bool) matcher {
+	return func(n *html.Node) bool {
+		i := position(n, fromEnd, ofType)
+		if a == 0 {
+			return i == b
+		}
+		d := i - b
+		return d%a == 0 && d/a >= 0
+	}
+}
+
+// parseNth parses the argument an+b of the :nth-* pseudo-classes.
+func parseNth(v string) (a, b int, err error) {
+	v = strings.ToLower(strings.Join(strings.Fields(v), ""))
+	switch v {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+	i := strings.IndexByte(v, 'n')
+	if i < 0 {
+		b, err = strconv.Atoi(v)
+		return a, b, err
+	}
+	switch s := v[:i]; s {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		a, err = strconv.Atoi(s)
+	}
+	if err == nil && i+1 < len(v) {
+		b, err = strconv.Atoi(v[i+1:])
+	}
+	return a, b, err
+}
+
+func textContent(n *html.Node) string {
+	var o strings.Builder
+	for nn := range n.Descendants() {
+		if nn.Type == html.TextNode {
+			o.WriteString(nn.Data)
+		}
+	}
+	return o.String()
+}
+
+// parser reads the selector using the recursive descent.
+type parser struct {
+	s   string
+	pos int
+}
+
+func parseSelector(s string) (selectorList, error) {
+	p := &parser{s: s}
+	return p.list(false)
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SelectorError{Selector: p.s, Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *parser) skipSpace() bool {
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\n\r\f", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *parser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// list reads the selector list, the nested list is the argument of the pseudo-class, it ends with the ')'.
+func (p *parser) list(nested bool) (selectorList, error) {
+	var o selectorList
+	for {
+		p.skipSpace()
+		s, err := p.complex()
+		if err != nil {
+			return nil, err
+		}
+		o = append(o, s)
+		p.skipSpace()
+		switch {
+		case p.peek() == ',':
+			p.pos++
+		case nested && p.peek() == ')':
+			return o, nil
+		case p.eof() && !nested:
+			return o, nil
+		case p.eof():
+			return nil, p.errorf("expected ')'")
+		default:
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+	}
+}
+
+func (p *parser) complex() (complexSelector, error) {
+	var o complexSelector
+	for {
+		s, err := p.compound()
+		if err != nil {
+			return o, err
+		}
+		o.compounds = append(o.compounds, s)
+
+		spaced := p.skipSpace()
+		switch c := p.peek(); c {
+		case 0, ',', ')':
+			return o, nil
+		case '>', '+', '~':
+			p.pos++
+			p.skipSpace()
+			o.combinators = append(o.combinators, combinator(c))
+		default:
+			if !spaced {
+				return o, p.errorf("unexpected %q", c)
+			}
+			o.combinators = append(o.combinators, descendant)
+		}
+	}
+}
+
+func (p *parser) compound() (compoundSelector, error) {
+	var o compoundSelector
+	start := p.pos
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+	case isNameStart(c):
+		o.tag = strings.ToLower(p.ident())
+		o.tagAtom = atom.Lookup([]byte(o.tag))
+		// the names of the custom elements contain the hyphen
+		if o.tagAtom == 0 && !strings.Contains(o.tag, "-") {
+			return o, p.errorf("unknown element %q", o.tag)
+		}
+	}
+
+	for {
+		var (
+			m   matcher
+			err error
+		)
+		switch p.peek() {
+		case '#':
+			p.pos++
+			m, err = p.nameMatcher("id", "=")
+		case '.':
+			p.pos++
+			m, err = p.nameMatcher("class", "~=")
+		case '[':
+			m, err = p.attribute()
+		case ':':
+			m, err = p.pseudo()
+		default:
+			if p.pos == start {
+				return o, p.errorf("expected selector")
+			}
+			return o, nil
+		}
+		if err != nil {
+			return o, err
+		}
+		o.conds = append(o.conds, m)
+	}
+}
+
+func (p *parser) nameMatcher(key, op string) (matcher, error) {
+	v := p.ident()
+	if v == "" {
+		return nil, p.errorf("expected name")
+	}
+	return attrMatcher(key, op, v, false), nil
+}
+
+func (p *parser) attribute() (matcher, error) {
+	p.pos++
+	p.skipSpace()
+	key := strings.ToLower(p.ident())
+	if key == "" {
+		return nil, p.errorf("expected attribute name")
+	}
+	p.skipSpace()
+
+	var (
+		op, val string
+		fold    bool
+		err     error
+	)
+	for _, v := range []string{"~=", "|=", "^=", "$=", "*=", "="} {
+		if strings.HasPrefix(p.s[p.pos:], v) {
+			op = v
+			p.pos += len(v)
+			break
+		}
+	}
+	if op != "" {
+		p.skipSpace()
+		if val, err = p.value(); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		switch p.peek() {
+		case 'i', 'I':
+			fold = true
+			p.pos++
+		case 's', 'S':
+			p.pos++
+		}
+		p.skipSpace()
+	}
+	if err = p.expect(']'); err != nil {
+		return nil, err
+	}
+	return attrMatcher(key, op, val, fold), nil
+}
+
+func (p *parser) pseudo() (matcher, error) {
+	p.pos++
+	name := strings.ToLower(p.ident())
+	switch name {
+	case "first-child":
+		return nthMatcher(0, 1, false, false), nil
+	case "last-child":
+		return nthMatcher(0, 1, true, false), nil
+	case "only-child":
+		return func(n *html.Node) bool {
+			return position(n, false, false) == 1 && position(n, true, false) == 1
+		}, nil
+	case "first-of-type":
+		return nthMatcher(0, 1, false, true), nil
+	case "last-of-type":
+		return nthMatcher(0, 1, true, true), nil
+	case "only-of-type":
+		return func(n *html.Node) bool {
+			return position(n, false, true) == 1 && position(n, true, true) == 1
+		}, nil
+	case "empty":
+		return func(n *html.Node) bool {
+			for c := range n.ChildNodes() {
+				if c.Type == html.ElementNode || c.Type == html.TextNode {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "root":
+		return func(n *html.Node) bool {
+			return n.Parent == nil || n.Parent.Type == html.DocumentNode
+		}, nil
+	case "nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type":
+		arg, err := p.argument()
+		if err != nil {
+			return nil, err
+		}
+		a, b, err := parseNth(arg)
+		if err != nil {
+			return nil, p.errorf("invalid argument %q of :%s", arg, name)
+		}
+		return nthMatcher(a, b, strings.Contains(name, "last"), strings.HasSuffix(name, "of-type")), nil
+	case "not":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		l, err := p.list(true)
+		if err == nil {
+			err = p.expect(')')
+		}
+		if err != nil {
+			return nil, err
+		}
+		return func(n *html.Node) bool {
+			return !l.match(n)
+		}, nil
+	case "contains":
+		text, err := p.argument()
+		if err != nil {
+			return nil, err
+		}
+		return func(n *html.Node) bool {
+			return strings.Contains(textContent(n), text)
+		}, nil
+	default:
+		return nil, p.errorf("unsupported pseudo-class %q", name)
+	}
+}
+
+// argument reads the pseudo-class's argument enclosed in the parentheses, the quotes are removed.
+func (p *parser) argument() (string, error) {
+	if err := p.expect('('); err != nil {
+		return "", err
+	}
+	p.skipSpace()
+	var (
+		o   string
+		err error
+	)
+	switch p.peek() {
+	case '"', '\'':
+		o, err = p.value()
+	default:
+		end := strings.IndexByte(p.s[p.pos:], ')')
+		if end < 0 {
+			return "", p.errorf("expected ')'")
+		}
+		o = strings.TrimSpace(p.s[p.pos : p.pos+end])
+		p.pos += end
+	}
+	if err == nil {
+		p.skipSpace()
+		err = p.expect(')')
+	}
+	return o, err
+}
+
+// value reads the quoted string, or the identifier.
+func (p *parser) value() (string, error) {
+	q := p.peek()
+	if q != '"' && q != '\'' {
+		v := p.ident()
+		if v == "" {
+			return "", p.errorf("expected value")
+		}
+		return v, nil
+	}
+	p.pos++
+	var o strings.Builder
+	for !p.eof() {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == q:
+			return o.String(), nil
+		case c == '\\' && !p.eof():
+			o.WriteByte(p.s[p.pos])
+			p.pos++
+		default:
+			o.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// ident reads the name, the backslash escapes the following character.
+func (p *parser) ident() string {
+	var o strings.Builder
+	for !p.eof() {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s):
+			o.WriteByte(p.s[p.pos+1])
+			p.pos += 2
+		case isNameStart(c) || (c >= '0' && c <= '9'):
+			o.WriteByte(c)
+			p.pos++
+		default:
+			return o.String()
+		}
+	}
+	return o.String()
+}
+
+func isNameStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '-' || c >= 0x80 || c == '\\'
+}
@@ -0,0 +1,210 @@
+package htmlfilter
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+const selectorDoc = `<html><body>
+<div id="main" class="product main">
+	<h1 id="title">Cohiba Siglo VI</h1>
+	<ul id="attrs" class="attributes">
+		<li id="li1" class="attr" data-key="length">Length <span id="s1">150 mm</span></li>
+		<li id="li2" class="attr odd" data-key="ring-gauge">Ring <span id="s2">52</span></li>
+		<li id="li3" class="attr" data-key="Origin" lang="en-US">Origin <span id="s3">Cuba</span></li>
+		<li id="li4"></li>
+	</ul>
+	<p id="price" data-eurval="25.5">25,50 €</p>
+	<p id="note">Out of stock</p>
+	<my-widget id="widget"></my-widget>
+</div>
+<div id="footer"><p id="copy">(c) cigars</p></div>
+</body></html>`
+
+func ids(v []Node) []string {
+	var o []string
+	for _, n := range v {
+		if id, ok := n.Attr("id"); ok {
+			o = append(o, id)
+		}
+	}
+	return o
+}
+
+func TestNode_FindAll(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorDoc))
+	assert.NoError(t, err)
+	n := Node{doc}
+
+	tests := map[string]struct {
+		selector string
+		want     []string
+	}{
+		"tag":                       {selector: "h1", want: []string{"title"}},
+		"universal":                 {selector: "ul > *", want: []string{"li1", "li2", "li3", "li4"}},
+		"id":                        {selector: "#price", want: []string{"price"}},
+		"classes":                   {selector: "li.attr.odd", want: []string{"li2"}},
+		"descendant":                {selector: "div.product span", want: []string{"s1", "s2", "s3"}},
+		"child":                     {selector: "body > div > p", want: []string{"price", "note", "copy"}},
+		"child excludes deeper":     {selector: "div#main > span"},
+		"next sibling":              {selector: "h1 + ul", want: []string{"attrs"}},
+		"subsequent sibling":        {selector: "ul ~ p", want: []string{"price", "note"}},
+		"attribute exists":          {selector: "[data-eurval]", want: []string{"price"}},
+		"attribute equals":          {selector: `li[data-key="length"]`, want: []string{"li1"}},
+		"attribute equals unquoted": {selector: "li[data-key=length]", want: []string{"li1"}},
+		"attribute case-insensitive": {
+			selector: "li[data-key='origin' i]", want: []string{"li3"},
+		},
+		"attribute word":      {selector: "[class~=odd]", want: []string{"li2"}},
+		"attribute lang":      {selector: "[lang|=en]", want: []string{"li3"}},
+		"attribute prefix":    {selector: "[data-key^=ring]", want: []string{"li2"}},
+		"attribute suffix":    {selector: "[data-key$=gauge]", want: []string{"li2"}},
+		"attribute substring": {selector: "[data-key*=in]", want: []string{"li2", "li3"}},
+		"nth-child":           {selector: "li:nth-child(2)", want: []string{"li2"}},
+		"nth-child odd":       {selector: "li:nth-child(odd)", want: []string{"li1", "li3"}},
+		"nth-child formula":   {selector: "li:nth-child(-n+2)", want: []string{"li1", "li2"}},
+		"nth-last-child":      {selector: "li:nth-last-child(1)", want: []string{"li4"}},
+		"first and last":      {selector: "li:first-child, li:last-child", want: []string{"li1", "li4"}},
+		"nth-of-type":         {selector: "#main > p:nth-of-type(2)", want: []string{"note"}},
+		"only-child":          {selector: "p:only-child", want: []string{"copy"}},
+		"empty":               {selector: "li:empty", want: []string{"li4"}},
+		"not":                 {selector: "li:not(.odd, :empty)", want: []string{"li1", "li3"}},
+		"contains":            {selector: `li:contains("Ring") > span`, want: []string{"s2"}},
+		"contains unquoted":   {selector: "p:contains(stock)", want: []string{"note"}},
+		"selector list in document order": {
+			selector: "p#copy, h1", want: []string{"title", "copy"},
+		},
+		"custom element": {selector: "my-widget", want: []string{"widget"}},
+		"no match":       {selector: "table td"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []Node
+			for v := range n.FindAll(test.selector) {
+				got = append(got, v)
+			}
+			assert.Equal(t, test.want, ids(got))
+		})
+	}
+
+	t.Run("scoped to the node's descendants", func(t *testing.T) {
+		footer, ok := n.First("#footer")
+		assert.True(t, ok)
+		var got []Node
+		for v := range footer.FindAll("div p") {
+			got = append(got, v)
+		}
+		assert.Equal(t, []string{"copy"}, ids(got))
+	})
+
+	t.Run("invalid selector panics", func(t *testing.T) {
+		for _, s := range []string{"", "div >", "li:nth-child(x)", "[data", "foo", "p:hover", "a,,b"} {
+			assert.Panics(t, func() {
+				for range n.FindAll(s) {
+				}
+			}, s)
+		}
+	})
+}
+
+func TestSelector_Find(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorDoc))
+	assert.NoError(t, err)
+	n := Node{doc}
+
+	tests := map[string][]string{
+		"li.attr":                 {"li1", "li2", "li3"},
+		"li.odd":                  {"li2"},
+		"p":                       {"price", "note"},
+		`div[class*="mai"]`:       {"main"},
+		"span":                    {"s1"},
+		"ul#attrs > li:not(.odd)": {"li1", "li3", "li4"},
+		"table":                   nil,
+	}
+	for selector, want := range tests {
+		t.Run(selector, func(t *testing.T) {
+			assert.Equal(t, want, ids(slices.Collect(MustCompile(selector).Find(n))))
+		})
+	}
+}
+
+func TestNode_First(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorDoc))
+	assert.NoError(t, err)
+	n := Node{doc}
+
+	got, ok := n.First("li.attr span")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"s1"}, ids([]Node{got}))
+
+	_, ok = n.First("table")
+	assert.False(t, ok)
+}
+
+func TestNode_Closest(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorDoc))
+	assert.NoError(t, err)
+	n := Node{doc}
+
+	span, _ := n.First("#s2")
+
+	got, ok := span.Closest("li")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"li2"}, ids([]Node{got}))
+
+	got, ok = span.Closest("span")
+	assert.True(t, ok, "the node itself matches")
+	assert.Equal(t, []string{"s2"}, ids([]Node{got}))
+
+	got, ok = span.Closest("div.product")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"main"}, ids([]Node{got}))
+
+	_, ok = span.Closest("#footer")
+	assert.False(t, ok)
+}
+
+func TestCompile(t *testing.T) {
+	t.Run("valid selector is cached", func(t *testing.T) {
+		const selector = "ul.attributes > li[data-key]"
+		got, err := Compile(selector)
+		assert.NoError(t, err)
+		assert.Equal(t, selector, got.String())
+
+		cached, ok := compiled.Load(selector)
+		assert.True(t, ok)
+		assert.Equal(t, got.String(), cached.(Selector).String())
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		_, err := Compile("li:nth-child(x)")
+		var want *SelectorError
+		assert.ErrorAs(t, err, &want)
+		assert.Equal(t, "li:nth-child(x)", want.Selector)
+
+		_, ok := compiled.Load("li:nth-child(x)")
+		assert.False(t, ok)
+	})
+
+	t.Run("must compile panics", func(t *testing.T) {
+		assert.Panics(t, func() { MustCompile("dvi") })
+	})
+}
+
+func TestSelector_Match(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorDoc))
+	assert.NoError(t, err)
+
+	s := MustCompile("li.attr")
+	n := Node{doc}
+	li, _ := n.First("#li2")
+	assert.True(t, s.Match(li))
+	span, _ := n.First("#s2")
+	assert.False(t, s.Match(span))
+	assert.False(t, s.Match(Node{}))
+}
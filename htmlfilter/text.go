@@ -0,0 +1,246 @@
+package htmlfilter
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Attr returns the value of the node's attribute.
+func (n Node) Attr(key string) (string, bool) {
+	if n.Node != nil {
+		for _, att := range n.Node.Attr {
+			if att.Key == key {
+				return att.Val, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Href returns the node's href attribute resolved against the base URL.
+// The attribute is returned as is if the base URL is empty.
+func (n Node) Href(base string) (string, bool) {
+	v, ok := n.Attr("href")
+	v = strings.TrimSpace(v)
+	if !ok || v == "" || base == "" {
+		return v, ok && v != ""
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", false
+	}
+	ref, err := url.Parse(v)
+	if err != nil {
+		return "", false
+	}
+	return b.ResolveReference(ref).String(), true
+}
+
+// Text returns the text of the node and its descendants with the whitespaces, including the non-breaking spaces,
+// collapsed to a single space. The text of the block elements and the line breaks are separated by a space,
+// the content of the scripts and styles is skipped.
+func (n Node) Text() string {
+	if n.Node == nil {
+		return ""
+	}
+	var o strings.Builder
+	writeText(&o, n.Node)
+	return normalizeSpace(o.String())
+}
+
+// OwnText returns the normalised text of the node's child text nodes, see Text.
+func (n Node) OwnText() string {
+	if n.Node == nil {
+		return ""
+	}
+	var o strings.Builder
+	for c := range n.ChildNodes() {
+		if c.Type == html.TextNode {
+			o.WriteString(c.Data)
+		}
+	}
+	return normalizeSpace(o.String())
+}
+
+func writeText(o *strings.Builder, n *html.Node) {
+	switch {
+	case n.Type == html.TextNode:
+		o.WriteString(n.Data)
+	case skipElement(n):
+	case n.DataAtom == atom.Br:
+		o.WriteString(" ")
+	default:
+		block := isBlock(n)
+		if block {
+			o.WriteString(" ")
+		}
+		for c := range n.ChildNodes() {
+			writeText(o, c)
+		}
+		if block {
+			o.WriteString(" ")
+		}
+	}
+}
+
+// normalizeSpace collapses the whitespaces, including the non-breaking space.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func skipElement(n *html.Node) bool {
+	if n.Type == html.CommentNode {
+		return true
+	}
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Iframe, atom.Object, atom.Head:
+		return true
+	}
+	return false
+}
+
+func isBlock(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Aside, atom.Nav,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Ul, atom.Ol, atom.Li, atom.Dl, atom.Dt, atom.Dd,
+		atom.Table, atom.Tr, atom.Td, atom.Th, atom.Blockquote, atom.Pre, atom.Hr:
+		return true
+	}
+	return false
+}
+
+// Markdown converts the content of the node to Markdown.
+// Only the text, the headings, the paragraphs, the lists, the emphasis and the links are kept. The raw html is never
+// written, the scripts, styles and embedded objects are skipped, and the links with the schemes other than http and
+// https are replaced with their text.
+func (n Node) Markdown() string {
+	if n.Node == nil {
+		return ""
+	}
+	var o strings.Builder
+	for c := range n.ChildNodes() {
+		writeMarkdown(&o, c)
+	}
+
+	var (
+		lines []string
+		blank bool
+	)
+	for _, line := range strings.Split(o.String(), "\n") {
+		line = normalizeSpace(line)
+		switch {
+		case line == "":
+			blank = len(lines) > 0
+		default:
+			if blank {
+				lines = append(lines, "")
+				blank = false
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func writeMarkdown(o *strings.Builder, n *html.Node) {
+	switch {
+	case n.Type == html.TextNode:
+		o.WriteString(escapeMarkdown(n.Data))
+		return
+	case n.Type != html.ElementNode || skipElement(n):
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		o.WriteString("\n")
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(n.Data[1:])
+		o.WriteString("\n\n" + strings.Repeat("#", level) + " ")
+		o.WriteString(strings.ReplaceAll(innerMarkdown(n), "\n", " "))
+		o.WriteString("\n\n")
+	case atom.P, atom.Blockquote, atom.Pre, atom.Table, atom.Dl:
+		o.WriteString("\n\n" + innerMarkdown(n) + "\n\n")
+	case atom.Ul, atom.Ol:
+		o.WriteString("\n\n")
+		var i int
+		for c := range n.ChildNodes() {
+			if c.DataAtom != atom.Li {
+				continue
+			}
+			i++
+			prefix := "- "
+			if n.DataAtom == atom.Ol {
+				prefix = strconv.Itoa(i) + ". "
+			}
+			o.WriteString(prefix + strings.ReplaceAll(strings.TrimSpace(innerMarkdown(c)), "\n\n", "\n") + "\n")
+		}
+		o.WriteString("\n\n")
+	case atom.Strong, atom.B:
+		writeEmphasis(o, innerMarkdown(n), "**")
+	case atom.Em, atom.I:
+		writeEmphasis(o, innerMarkdown(n), "*")
+	case atom.A:
+		text := innerMarkdown(n)
+		href, _ := Node{n}.Href("")
+		switch u, err := url.Parse(href); {
+		case strings.TrimSpace(text) == "":
+		case err != nil || href == "" || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https"):
+			o.WriteString(text)
+		default:
+			lead, core, trail := splitSpace(text)
+			o.WriteString(lead + "[" + core + "](" + strings.ReplaceAll(href, " ", "%20") + ")" + trail)
+		}
+	default:
+		block := isBlock(n)
+		if block {
+			o.WriteString("\n")
+		}
+		for c := range n.ChildNodes() {
+			writeMarkdown(o, c)
+		}
+		if block {
+			o.WriteString("\n")
+		}
+	}
+}
+
+func innerMarkdown(n *html.Node) string {
+	var o strings.Builder
+	for c := range n.ChildNodes() {
+		writeMarkdown(&o, c)
+	}
+	return o.String()
+}
+
+func writeEmphasis(o *strings.Builder, s, mark string) {
+	lead, core, trail := splitSpace(s)
+	switch core {
+	case "":
+		o.WriteString(s)
+	default:
+		o.WriteString(lead + mark + core + mark + trail)
+	}
+}
+
+// splitSpace splits the string into its leading whitespaces, the trimmed string and the trailing whitespaces.
+func splitSpace(s string) (lead, core, trail string) {
+	core = strings.TrimSpace(s)
+	if core == "" {
+		return s, "", ""
+	}
+	i := strings.Index(s, core)
+	return s[:i], core, s[i+len(core):]
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
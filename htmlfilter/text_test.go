@@ -0,0 +1,117 @@
+package htmlfilter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func mustParse(t *testing.T, s string) Node {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(s))
+	assert.NoError(t, err)
+	return Node{doc}
+}
+
+func TestNode_Attr(t *testing.T) {
+	n := mustParse(t, `<p id="price" data-eurval="25.5" data-empty="">25,50 €</p>`)
+	p, _ := n.First("p")
+
+	v, ok := p.Attr("data-eurval")
+	assert.True(t, ok)
+	assert.Equal(t, "25.5", v)
+
+	v, ok = p.Attr("data-empty")
+	assert.True(t, ok)
+	assert.Empty(t, v)
+
+	_, ok = p.Attr("href")
+	assert.False(t, ok)
+
+	_, ok = Node{}.Attr("id")
+	assert.False(t, ok)
+}
+
+func TestNode_Href(t *testing.T) {
+	n := mustParse(t, `<a id="rel" href=" /zigarren/cohiba?page=2 "></a><a id="abs" href="https://example.com/x"></a>
+<a id="empty" href=""></a><a id="none"></a>`)
+
+	tests := map[string]struct {
+		id, base string
+		want     string
+		wantOK   bool
+	}{
+		"relative as is": {
+			id: "rel", want: "/zigarren/cohiba?page=2", wantOK: true,
+		},
+		"relative resolved": {
+			id: "rel", base: "https://www.cigarworld.de/zigarren/kuba",
+			want: "https://www.cigarworld.de/zigarren/cohiba?page=2", wantOK: true,
+		},
+		"absolute": {
+			id: "abs", base: "https://www.cigarworld.de", want: "https://example.com/x", wantOK: true,
+		},
+		"empty":        {id: "empty", base: "https://www.cigarworld.de"},
+		"no attribute": {id: "none", base: "https://www.cigarworld.de"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, _ := n.First("#" + test.id)
+			got, ok := a.Href(test.base)
+			assert.Equal(t, test.wantOK, ok)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestNode_Text(t *testing.T) {
+	n := mustParse(t, "<div id=\"d\">\n  <h1>Cohiba  <span>Siglo VI</span></h1><p>Länge<br>150&nbsp;mm</p>"+
+		"<script>var x = 1;</script><style>p {}</style><!-- comment -->\n</div>")
+	d, _ := n.First("#d")
+	assert.Equal(t, "Cohiba Siglo VI Länge 150 mm", d.Text())
+
+	h1, _ := n.First("h1")
+	assert.Equal(t, "Cohiba", h1.OwnText())
+
+	assert.Empty(t, Node{}.Text())
+	assert.Empty(t, Node{}.OwnText())
+}
+
+func TestNode_Markdown(t *testing.T) {
+	tests := map[string]struct {
+		html string
+		want string
+	}{
+		"paragraphs with emphasis": {
+			html: "<p>Die exklusive<strong> Kollaboration</strong> zwischen <em>zwei</em> Marken.</p>\n   <p>Zweiter</p>",
+			want: "Die exklusive **Kollaboration** zwischen *zwei* Marken.\n\nZweiter",
+		},
+		"headings and lists": {
+			html: "<h2>Aromen</h2><ul><li>Holz</li><li> Leder </li></ul><ol><li>eins</li><li>zwei</li></ol>",
+			want: "## Aromen\n\n- Holz\n- Leder\n\n1. eins\n2. zwei",
+		},
+		"links": {
+			html: `<p><a href="/tatuaje" target="_self"><strong>Tatuaje</strong></a> ` +
+				`<a href="javascript:alert(1)">unsafe</a> <a href="https://example.com">ext</a> <a href="/x"> </a></p>`,
+			want: "[**Tatuaje**](/tatuaje) unsafe [ext](https://example.com)",
+		},
+		"line breaks": {
+			html: "<p>Länge<br>150 mm</p>",
+			want: "Länge\n150 mm",
+		},
+		"raw html is not written": {
+			html: `<div class="blanktag" style="background: url('x');"></div><script>alert(1)</script>` +
+				`<iframe src="https://example.com"></iframe><p>a &lt;b&gt; *c* [d]</p>`,
+			want: `a \<b> \*c\* \[d\]`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			n := mustParse(t, "<div id=\"root\">"+test.html+"</div>")
+			root, _ := n.First("#root")
+			assert.Equal(t, test.want, root.Markdown())
+		})
+	}
+}
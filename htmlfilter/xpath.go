@@ -0,0 +1,1073 @@
+package htmlfilter
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// XPath defines the compiled XPath 1.0 expression.
+//
+// Supported subset:
+//   - location paths: absolute and relative, the abbreviations /, //, ., .., @ and *;
+//   - axes: child, descendant, descendant-or-self, self, parent, ancestor, ancestor-or-self, following-sibling,
+//     preceding-sibling, following, preceding and attribute;
+//   - node tests: name, *, text(), node() and comment();
+//   - predicates, including the positional ones: //dd[1], //dt[strong='Length'];
+//   - operators: or, and, =, !=, <, <=, >, >=, +, -, *, div, mod and the union |;
+//   - functions: last, position, count, string, concat, contains, starts-with, substring-before, substring-after,
+//     normalize-space, string-length, not, true, false, boolean, number, name and local-name.
+//
+// The variables and the namespaces are not supported.
+type XPath struct {
+	s string
+	e xexpr
+}
+
+// compiledXPath caches the expressions compiled by the package.
+var compiledXPath sync.Map
+
+// CompileXPath parses the XPath expression, the result is cached, hence the expression is parsed once.
+// It returns SelectorError if the expression is invalid.
+func CompileXPath(expr string) (XPath, error) {
+	if v, ok := compiledXPath.Load(expr); ok {
+		return v.(XPath), nil
+	}
+	e, err := parseXPath(expr)
+	if err != nil {
+		return XPath{}, err
+	}
+	o := XPath{s: expr, e: e}
+	compiledXPath.Store(expr, o)
+	return o, nil
+}
+
+// MustCompileXPath is like CompileXPath, but panics if the expression is invalid.
+func MustCompileXPath(expr string) XPath {
+	o, err := CompileXPath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+func (x XPath) String() string {
+	return x.s
+}
+
+// All returns an iterator over the nodes selected by the expression evaluated with n as the context node.
+// The nodes are returned in the document order, the attributes are skipped, see Values.
+func (x XPath) All(n Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		for _, v := range x.nodes(n) {
+			if v.attr < 0 && !yield(Node{v.Node}) {
+				return
+			}
+		}
+	}
+}
+
+// First returns the first node selected by the expression, see All.
+func (x XPath) First(n Node) (Node, bool) {
+	for v := range x.All(n) {
+		return v, true
+	}
+	return Node{}, false
+}
+
+// Value evaluates the expression and converts the result to the string like the function string(), e.g.,
+// the value of //a/@href is the first link's href.
+func (x XPath) Value(n Node) string {
+	if n.Node == nil {
+		return ""
+	}
+	return toString(x.eval(n))
+}
+
+// Values returns the string-values of the nodes selected by the expression, including the attributes' values.
+func (x XPath) Values(n Node) []string {
+	var o []string
+	for _, v := range x.nodes(n) {
+		o = append(o, stringValue(v))
+	}
+	return o
+}
+
+func (x XPath) nodes(n Node) []xnode {
+	if n.Node == nil {
+		return nil
+	}
+	v, _ := x.eval(n).([]xnode)
+	return v
+}
+
+func (x XPath) eval(n Node) xvalue {
+	root := n.Node
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	return x.e.eval(xcontext{node: xnode{Node: n.Node, attr: -1}, pos: 1, size: 1, doc: &xdoc{root: root}})
+}
+
+// XPath returns an iterator over the nodes selected by the XPath expression evaluated with n as the context node,
+// see XPath.All. It panics if the expression is invalid, use CompileXPath to validate it.
+func (n Node) XPath(expr string) iter.Seq[Node] {
+	return MustCompileXPath(expr).All(n)
+}
+
+// xnode defines the node of the XPath data model.
+type xnode struct {
+	*html.Node
+	// attr the index of the attribute in the element's Attr, it is -1 if the node is not an attribute.
+	attr int
+}
+
+// xvalue defines the result of the expression: []xnode, string, float64, or bool.
+type xvalue any
+
+type xdoc struct {
+	root  *html.Node
+	order map[*html.Node]int
+}
+
+// sort sorts the nodes in the document order, the attributes follow their element.
+func (d *xdoc) sort(v []xnode) {
+	if d.order == nil {
+		d.order = make(map[*html.Node]int)
+		d.order[d.root] = 0
+		for n := range d.root.Descendants() {
+			d.order[n] = len(d.order)
+		}
+	}
+	slices.SortStableFunc(v, func(a, b xnode) int {
+		if c := d.order[a.Node] - d.order[b.Node]; c != 0 {
+			return c
+		}
+		return a.attr - b.attr
+	})
+}
+
+type xcontext struct {
+	node      xnode
+	pos, size int
+	doc       *xdoc
+}
+
+type xexpr interface {
+	eval(c xcontext) xvalue
+}
+
+type literalExpr string
+
+func (e literalExpr) eval(xcontext) xvalue {
+	return string(e)
+}
+
+type numberExpr float64
+
+func (e numberExpr) eval(xcontext) xvalue {
+	return float64(e)
+}
+
+type negExpr struct {
+	e xexpr
+}
+
+func (e negExpr) eval(c xcontext) xvalue {
+	return -toNumber(e.e.eval(c))
+}
+
+type binaryExpr struct {
+	op   string
+	l, r xexpr
+}
+
+func (e binaryExpr) eval(c xcontext) xvalue {
+	switch e.op {
+	case "or":
+		return toBool(e.l.eval(c)) || toBool(e.r.eval(c))
+	case "and":
+		return toBool(e.l.eval(c)) && toBool(e.r.eval(c))
+	case "=", "!=", "<", "<=", ">", ">=":
+		return compare(e.op, e.l.eval(c), e.r.eval(c))
+	case "|":
+		l, _ := e.l.eval(c).([]xnode)
+		r, _ := e.r.eval(c).([]xnode)
+		return union(c.doc, l, r)
+	}
+	l, r := toNumber(e.l.eval(c)), toNumber(e.r.eval(c))
+	var o float64
+	switch e.op {
+	case "+":
+		o = l + r
+	case "-":
+		o = l - r
+	case "*":
+		o = l * r
+	case "div":
+		o = l / r
+	case "mod":
+		o = math.Mod(l, r)
+	}
+	return o
+}
+
+// filterExpr applies the predicates to the result of the primary expression.
+type filterExpr struct {
+	e     xexpr
+	preds []xexpr
+}
+
+func (e filterExpr) eval(c xcontext) xvalue {
+	v := e.e.eval(c)
+	set, ok := v.([]xnode)
+	if !ok {
+		return v
+	}
+	for _, p := range e.preds {
+		set = filterNodes(c.doc, set, p)
+	}
+	return set
+}
+
+// pathExpr defines the location path which starts either from the context node, the document's root, or from the
+// result of the filter expression.
+type pathExpr struct {
+	root  bool
+	start xexpr
+	steps []xstep
+}
+
+func (e pathExpr) eval(c xcontext) xvalue {
+	var set []xnode
+	switch {
+	case e.root:
+		set = []xnode{{Node: c.doc.root, attr: -1}}
+	case e.start != nil:
+		set, _ = e.start.eval(c).([]xnode)
+	default:
+		set = []xnode{c.node}
+	}
+	for _, s := range e.steps {
+		set = s.apply(c.doc, set)
+	}
+	return set
+}
+
+type nodeTestKind int
+
+const (
+	testName nodeTestKind = iota
+	testAny
+	testText
+	testNode
+	testComment
+)
+
+type xstep struct {
+	axis     string
+	kind     nodeTestKind
+	name     string
+	nameAtom atom.Atom
+	preds    []xexpr
+}
+
+func (s xstep) apply(d *xdoc, in []xnode) []xnode {
+	var (
+		o    []xnode
+		seen = make(map[xnode]struct{})
+	)
+	for _, n := range in {
+		var candidates []xnode
+		for v := range axisNodes(d, s.axis, n) {
+			if s.test(v) {
+				candidates = append(candidates, v)
+			}
+		}
+		for _, p := range s.preds {
+			candidates = filterNodes(d, candidates, p)
+		}
+		for _, v := range candidates {
+			if _, ok := seen[v]; !ok {
+				seen[v] = struct{}{}
+				o = append(o, v)
+			}
+		}
+	}
+	d.sort(o)
+	return o
+}
+
+func (s xstep) test(n xnode) bool {
+	switch s.kind {
+	case testNode:
+		return true
+	case testText:
+		return n.attr < 0 && n.Type == html.TextNode
+	case testComment:
+		return n.attr < 0 && n.Type == html.CommentNode
+	}
+	if s.axis == "attribute" {
+		return n.attr >= 0 && (s.kind == testAny || n.Node.Attr[n.attr].Key == s.name)
+	}
+	if n.attr >= 0 || n.Type != html.ElementNode {
+		return false
+	}
+	switch {
+	case s.kind == testAny:
+		return true
+	case s.nameAtom != 0 && n.DataAtom != 0:
+		return n.DataAtom == s.nameAtom
+	default:
+		return n.Data == s.name
+	}
+}
+
+// filterNodes keeps the nodes which match the predicate, the number predicate matches the node's position.
+func filterNodes(d *xdoc, v []xnode, pred xexpr) []xnode {
+	var o []xnode
+	for i, n := range v {
+		r := pred.eval(xcontext{node: n, pos: i + 1, size: len(v), doc: d})
+		if f, ok := r.(float64); ok {
+			if f == float64(i+1) {
+				o = append(o, n)
+			}
+			continue
+		}
+		if toBool(r) {
+			o = append(o, n)
+		}
+	}
+	return o
+}
+
+func union(d *xdoc, l, r []xnode) []xnode {
+	var (
+		o    = make([]xnode, 0, len(l)+len(r))
+		seen = make(map[xnode]struct{})
+	)
+	for _, n := range slices.Concat(l, r) {
+		if _, ok := seen[n]; !ok {
+			seen[n] = struct{}{}
+			o = append(o, n)
+		}
+	}
+	d.sort(o)
+	return o
+}
+
+var xpathAxes = []string{
+	"child", "descendant", "descendant-or-self", "self", "parent", "ancestor", "ancestor-or-self",
+	"following-sibling", "preceding-sibling", "following", "preceding", "attribute",
+}
+
+// axisNodes returns the nodes of the axis in the axis' order, i.e., the reverse axes start from the nearest node.
+func axisNodes(d *xdoc, axis string, n xnode) iter.Seq[xnode] {
+	node := func(v *html.Node) xnode {
+		return xnode{Node: v, attr: -1}
+	}
+	return func(yield func(xnode) bool) {
+		isAttr := n.attr >= 0
+		switch axis {
+		case "self":
+			yield(n)
+		case "attribute":
+			if !isAttr {
+				for i := range n.Node.Attr {
+					if !yield(xnode{Node: n.Node, attr: i}) {
+						return
+					}
+				}
+			}
+		case "child", "descendant", "descendant-or-self":
+			if axis == "descendant-or-self" && !yield(n) {
+				return
+			}
+			if isAttr {
+				return
+			}
+			seq := n.ChildNodes()
+			if axis != "child" {
+				seq = n.Descendants()
+			}
+			for v := range seq {
+				if !yield(node(v)) {
+					return
+				}
+			}
+		case "parent", "ancestor", "ancestor-or-self":
+			if axis == "ancestor-or-self" && !yield(n) {
+				return
+			}
+			p := n.Parent
+			if isAttr {
+				p = n.Node
+			}
+			for ; p != nil; p = p.Parent {
+				if !yield(node(p)) || axis == "parent" {
+					return
+				}
+			}
+		case "following-sibling", "preceding-sibling":
+			if isAttr {
+				return
+			}
+			next := func(v *html.Node) *html.Node { return v.NextSibling }
+			if axis == "preceding-sibling" {
+				next = func(v *html.Node) *html.Node { return v.PrevSibling }
+			}
+			for s := next(n.Node); s != nil; s = next(s) {
+				if !yield(node(s)) {
+					return
+				}
+			}
+		case "following":
+			start := n.Node
+			for ; start != nil; start = start.Parent {
+				for s := start.NextSibling; s != nil; s = s.NextSibling {
+					if !yield(node(s)) {
+						return
+					}
+					for v := range s.Descendants() {
+						if !yield(node(v)) {
+							return
+						}
+					}
+				}
+			}
+		case "preceding":
+			var (
+				before    []*html.Node
+				ancestors = make(map[*html.Node]struct{})
+			)
+			for p := n.Node; p != nil; p = p.Parent {
+				ancestors[p] = struct{}{}
+			}
+			for v := range d.root.Descendants() {
+				if v == n.Node {
+					break
+				}
+				if _, ok := ancestors[v]; !ok {
+					before = append(before, v)
+				}
+			}
+			for _, v := range slices.Backward(before) {
+				if !yield(node(v)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func stringValue(n xnode) string {
+	if n.attr >= 0 {
+		return n.Node.Attr[n.attr].Val
+	}
+	switch n.Type {
+	case html.TextNode, html.CommentNode:
+		return n.Data
+	}
+	var o strings.Builder
+	for v := range n.Descendants() {
+		if v.Type == html.TextNode {
+			o.WriteString(v.Data)
+		}
+	}
+	return o.String()
+}
+
+func toString(v xvalue) string {
+	switch v := v.(type) {
+	case []xnode:
+		if len(v) > 0 {
+			return stringValue(v[0])
+		}
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN"
+		case math.IsInf(v, 1):
+			return "Infinity"
+		case math.IsInf(v, -1):
+			return "-Infinity"
+		case v == math.Trunc(v):
+			return strconv.FormatFloat(v, 'f', 0, 64)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func toNumber(v xvalue) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(toString(v)), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+func toBool(v xvalue) bool {
+	switch v := v.(type) {
+	case []xnode:
+		return len(v) > 0
+	case string:
+		return v != ""
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case bool:
+		return v
+	}
+	return false
+}
+
+// compare compares the values following the XPath 1.0 rules: the node-set matches if any of its nodes matches.
+func compare(op string, l, r xvalue) bool {
+	ln, lok := l.([]xnode)
+	rn, rok := r.([]xnode)
+	switch {
+	case lok && rok:
+		for _, a := range ln {
+			for _, b := range rn {
+				if compareAtoms(op, stringValue(a), stringValue(b)) {
+					return true
+				}
+			}
+		}
+		return false
+	case lok:
+		if b, ok := r.(bool); ok {
+			return compareAtoms(op, len(ln) > 0, b)
+		}
+		for _, a := range ln {
+			if compareAtoms(op, stringValue(a), r) {
+				return true
+			}
+		}
+		return false
+	case rok:
+		flipped := map[string]string{"<": ">", "<=": ">=", ">": "<", ">=": "<="}[op]
+		if flipped == "" {
+			flipped = op
+		}
+		return compare(flipped, r, l)
+	}
+	return compareAtoms(op, l, r)
+}
+
+func compareAtoms(op string, l, r xvalue) bool {
+	if op == "=" || op == "!=" {
+		var eq bool
+		_, lBool := l.(bool)
+		_, rBool := r.(bool)
+		_, lNum := l.(float64)
+		_, rNum := r.(float64)
+		switch {
+		case lBool || rBool:
+			eq = toBool(l) == toBool(r)
+		case lNum || rNum:
+			eq = toNumber(l) == toNumber(r)
+		default:
+			eq = toString(l) == toString(r)
+		}
+		return eq == (op == "=")
+	}
+	a, b := toNumber(l), toNumber(r)
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+type funcExpr struct {
+	name string
+	args []xexpr
+}
+
+// xpathFunctions defines the min and max number of the functions' arguments, -1 means no limit.
+var xpathFunctions = map[string][2]int{
+	"last": {0, 0}, "position": {0, 0}, "count": {1, 1}, "string": {0, 1}, "concat": {2, -1},
+	"contains": {2, 2}, "starts-with": {2, 2}, "substring-before": {2, 2}, "substring-after": {2, 2},
+	"normalize-space": {0, 1}, "string-length": {0, 1}, "not": {1, 1}, "true": {0, 0}, "false": {0, 0},
+	"boolean": {1, 1}, "number": {0, 1}, "name": {0, 1}, "local-name": {0, 1},
+}
+
+func (e funcExpr) eval(c xcontext) xvalue {
+	arg := func(i int) xvalue {
+		if i < len(e.args) {
+			return e.args[i].eval(c)
+		}
+		return []xnode{c.node}
+	}
+	str := func(i int) string {
+		return toString(arg(i))
+	}
+
+	switch e.name {
+	case "last":
+		return float64(c.size)
+	case "position":
+		return float64(c.pos)
+	case "count":
+		v, _ := arg(0).([]xnode)
+		return float64(len(v))
+	case "string":
+		return str(0)
+	case "concat":
+		var o strings.Builder
+		for i := range e.args {
+			o.WriteString(str(i))
+		}
+		return o.String()
+	case "contains":
+		return strings.Contains(str(0), str(1))
+	case "starts-with":
+		return strings.HasPrefix(str(0), str(1))
+	case "substring-before":
+		v, _, _ := strings.Cut(str(0), str(1))
+		if !strings.Contains(str(0), str(1)) {
+			v = ""
+		}
+		return v
+	case "substring-after":
+		_, v, _ := strings.Cut(str(0), str(1))
+		return v
+	case "normalize-space":
+		return normalizeSpace(str(0))
+	case "string-length":
+		return float64(utf8.RuneCountInString(str(0)))
+	case "not":
+		return !toBool(arg(0))
+	case "true":
+		return true
+	case "false":
+		return false
+	case "boolean":
+		return toBool(arg(0))
+	case "number":
+		return toNumber(arg(0))
+	case "name", "local-name":
+		var o string
+		if v, _ := arg(0).([]xnode); len(v) > 0 {
+			switch n := v[0]; {
+			case n.attr >= 0:
+				o = n.Node.Attr[n.attr].Key
+			case n.Type == html.ElementNode:
+				o = n.Data
+			}
+		}
+		return o
+	}
+	return nil
+}
+
+type xtokenKind int
+
+const (
+	tokenEOF xtokenKind = iota
+	tokenName
+	tokenString
+	tokenNumber
+	tokenPunct
+)
+
+type xtoken struct {
+	kind xtokenKind
+	val  string
+	pos  int
+}
+
+func (t xtoken) is(punct string) bool {
+	return t.kind == tokenPunct && t.val == punct
+}
+
+func (t xtoken) isName(name string) bool {
+	return t.kind == tokenName && t.val == name
+}
+
+func tokenizeXPath(s string) ([]xtoken, error) {
+	var o []xtoken
+	for i := 0; i < len(s); {
+		c := s[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, &SelectorError{Selector: s, Pos: i, Msg: "unterminated string"}
+			}
+			o = append(o, xtoken{kind: tokenString, val: s[i+1 : i+1+end], pos: start})
+			i += end + 2
+			continue
+		case isDigit(c) || (c == '.' && i+1 < len(s) && isDigit(s[i+1])):
+			for i < len(s) && (isDigit(s[i]) || s[i] == '.') {
+				i++
+			}
+			o = append(o, xtoken{kind: tokenNumber, val: s[start:i], pos: start})
+			continue
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c >= 0x80:
+			for i < len(s) && (isNameStart(s[i]) && s[i] != '\\' || isDigit(s[i]) || s[i] == '.') {
+				i++
+			}
+			o = append(o, xtoken{kind: tokenName, val: s[start:i], pos: start})
+			continue
+		}
+
+		var punct string
+		for _, v := range []string{"//", "::", "..", "!=", "<=", ">=", "/", "(", ")", "[", "]", ".", "@", ",", "|",
+			"+", "-", "=", "<", ">", "*"} {
+			if strings.HasPrefix(s[i:], v) {
+				punct = v
+				break
+			}
+		}
+		if punct == "" {
+			return nil, &SelectorError{Selector: s, Pos: i, Msg: fmt.Sprintf("unexpected %q", c)}
+		}
+		o = append(o, xtoken{kind: tokenPunct, val: punct, pos: start})
+		i += len(punct)
+	}
+	return append(o, xtoken{kind: tokenEOF, pos: len(s)}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+type xparser struct {
+	s      string
+	tokens []xtoken
+	i      int
+}
+
+func parseXPath(s string) (xexpr, error) {
+	tokens, err := tokenizeXPath(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &xparser{s: s, tokens: tokens}
+	e, err := p.expr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = p.errorf("unexpected %q", p.peek().val)
+	}
+	return e, err
+}
+
+func (p *xparser) errorf(format string, args ...any) error {
+	return &SelectorError{Selector: p.s, Pos: p.peek().pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *xparser) peek() xtoken {
+	return p.tokens[p.i]
+}
+
+func (p *xparser) peekAt(i int) xtoken {
+	if p.i+i < len(p.tokens) {
+		return p.tokens[p.i+i]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *xparser) next() xtoken {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *xparser) expect(punct string) error {
+	if !p.peek().is(punct) {
+		return p.errorf("expected %q", punct)
+	}
+	p.next()
+	return nil
+}
+
+func (p *xparser) expr() (xexpr, error) {
+	return p.binary(0)
+}
+
+// xpathOperators defines the binary operators by their precedence, from the lowest.
+var xpathOperators = [][]string{
+	{"or"}, {"and"}, {"=", "!="}, {"<", "<=", ">", ">="}, {"+", "-"}, {"*", "div", "mod"},
+}
+
+func (p *xparser) binary(level int) (xexpr, error) {
+	if level == len(xpathOperators) {
+		return p.unary()
+	}
+	l, err := p.binary(level + 1)
+	for err == nil {
+		t := p.peek()
+		var op string
+		for _, v := range xpathOperators[level] {
+			if t.is(v) || t.isName(v) {
+				op = v
+			}
+		}
+		if op == "" {
+			break
+		}
+		p.next()
+		var r xexpr
+		if r, err = p.binary(level + 1); err == nil {
+			l = binaryExpr{op: op, l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+func (p *xparser) unary() (xexpr, error) {
+	if p.peek().is("-") {
+		p.next()
+		e, err := p.unary()
+		return negExpr{e: e}, err
+	}
+	l, err := p.path()
+	for err == nil && p.peek().is("|") {
+		p.next()
+		var r xexpr
+		if r, err = p.path(); err == nil {
+			l = binaryExpr{op: "|", l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+var descendantOrSelf = xstep{axis: "descendant-or-self", kind: testNode}
+
+func (p *xparser) path() (xexpr, error) {
+	switch t := p.peek(); {
+	case t.is("/"):
+		p.next()
+		e := pathExpr{root: true}
+		var err error
+		if p.isStepStart() {
+			e.steps, err = p.relativePath()
+		}
+		return e, err
+	case t.is("//"):
+		p.next()
+		steps, err := p.relativePath()
+		return pathExpr{root: true, steps: append([]xstep{descendantOrSelf}, steps...)}, err
+	case p.isStepStart():
+		steps, err := p.relativePath()
+		return pathExpr{steps: steps}, err
+	}
+
+	e, err := p.filter()
+	if err == nil && (p.peek().is("/") || p.peek().is("//")) {
+		var steps []xstep
+		if p.next().is("//") {
+			steps = append(steps, descendantOrSelf)
+		}
+		var rest []xstep
+		if rest, err = p.relativePath(); err == nil {
+			e = pathExpr{start: e, steps: append(steps, rest...)}
+		}
+	}
+	return e, err
+}
+
+func isNodeType(name string) bool {
+	return name == "text" || name == "node" || name == "comment"
+}
+
+func (p *xparser) isStepStart() bool {
+	t := p.peek()
+	switch {
+	case t.is(".") || t.is("..") || t.is("@") || t.is("*"):
+		return true
+	case t.kind == tokenName:
+		next := p.peekAt(1)
+		return next.is("::") || !next.is("(") || isNodeType(t.val)
+	}
+	return false
+}
+
+func (p *xparser) relativePath() ([]xstep, error) {
+	s, err := p.step()
+	if err != nil {
+		return nil, err
+	}
+	o := []xstep{s}
+	for p.peek().is("/") || p.peek().is("//") {
+		if p.next().is("//") {
+			o = append(o, descendantOrSelf)
+		}
+		if s, err = p.step(); err != nil {
+			return nil, err
+		}
+		o = append(o, s)
+	}
+	return o, nil
+}
+
+func (p *xparser) step() (xstep, error) {
+	switch {
+	case p.peek().is("."):
+		p.next()
+		return xstep{axis: "self", kind: testNode}, nil
+	case p.peek().is(".."):
+		p.next()
+		return xstep{axis: "parent", kind: testNode}, nil
+	}
+
+	var o = xstep{axis: "child"}
+	switch t := p.peek(); {
+	case t.is("@"):
+		p.next()
+		o.axis = "attribute"
+	case t.kind == tokenName && p.peekAt(1).is("::"):
+		if !slices.Contains(xpathAxes, t.val) {
+			return o, p.errorf("unsupported axis %q", t.val)
+		}
+		o.axis = t.val
+		p.next()
+		p.next()
+	}
+
+	switch t := p.peek(); {
+	case t.is("*"):
+		p.next()
+		o.kind = testAny
+	case t.kind == tokenName && isNodeType(t.val) && p.peekAt(1).is("("):
+		p.next()
+		p.next()
+		if err := p.expect(")"); err != nil {
+			return o, err
+		}
+		o.kind = map[string]nodeTestKind{"text": testText, "node": testNode, "comment": testComment}[t.val]
+	case t.kind == tokenName:
+		p.next()
+		o.kind = testName
+		o.name = strings.ToLower(t.val)
+		if o.axis != "attribute" {
+			o.nameAtom = atom.Lookup([]byte(o.name))
+		}
+	default:
+		return o, p.errorf("expected node test")
+	}
+
+	var err error
+	o.preds, err = p.predicates()
+	return o, err
+}
+
+func (p *xparser) predicates() ([]xexpr, error) {
+	var o []xexpr
+	for p.peek().is("[") {
+		p.next()
+		e, err := p.expr()
+		if err == nil {
+			err = p.expect("]")
+		}
+		if err != nil {
+			return nil, err
+		}
+		o = append(o, e)
+	}
+	return o, nil
+}
+
+func (p *xparser) filter() (xexpr, error) {
+	e, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	preds, err := p.predicates()
+	if len(preds) > 0 {
+		e = filterExpr{e: e, preds: preds}
+	}
+	return e, err
+}
+
+func (p *xparser) primary() (xexpr, error) {
+	switch t := p.peek(); t.kind {
+	case tokenString:
+		p.next()
+		return literalExpr(t.val), nil
+	case tokenNumber:
+		p.next()
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", t.val)
+		}
+		return numberExpr(f), nil
+	case tokenPunct:
+		if t.is("(") {
+			p.next()
+			e, err := p.expr()
+			if err == nil {
+				err = p.expect(")")
+			}
+			return e, err
+		}
+	case tokenName:
+		return p.function()
+	}
+	return nil, p.errorf("unexpected %q", p.peek().val)
+}
+
+func (p *xparser) function() (xexpr, error) {
+	t := p.next()
+	limits, ok := xpathFunctions[t.val]
+	if !ok {
+		return nil, &SelectorError{Selector: p.s, Pos: t.pos, Msg: fmt.Sprintf("unsupported function %q", t.val)}
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var o = funcExpr{name: t.val}
+	for !p.peek().is(")") {
+		if len(o.args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		o.args = append(o.args, e)
+	}
+	p.next()
+	if len(o.args) < limits[0] || (limits[1] >= 0 && len(o.args) > limits[1]) {
+		return nil, &SelectorError{
+			Selector: p.s, Pos: t.pos, Msg: fmt.Sprintf("wrong number of arguments of %s()", t.val),
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,142 @@
+package htmlfilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const xpathDoc = `<html><body>
+<div class="main_section">
+	<dl>
+		<dt id="dt1"><strong>Length</strong></dt><dd id="dd1">6.0 in</dd>
+		<dt id="dt2"><strong>Ring Gauge</strong></dt><dd id="dd2">52</dd>
+		<dt id="dt3"><strong>Country</strong></dt><dd id="dd3">Cuba<br>Havana</dd>
+	</dl>
+</div>
+<div class="dato">
+	<div id="n1" class="nombre">Origin</div><div id="v1" class="valor">Nicaragua</div>
+	<div id="n2" class="nombre">Brand</div><div id="v2" class="valor"><a href="/padron">Padrón</a></div>
+</div>
+<!-- note -->
+</body></html>`
+
+func TestXPath_All(t *testing.T) {
+	n := mustParse(t, xpathDoc)
+
+	tests := map[string]struct {
+		expr string
+		want []string
+	}{
+		"absolute path":                 {expr: "/html/body/div/dl/dd", want: []string{"dd1", "dd2", "dd3"}},
+		"descendant":                    {expr: "//dd", want: []string{"dd1", "dd2", "dd3"}},
+		"position":                      {expr: "//dd[2]", want: []string{"dd2"}},
+		"last":                          {expr: "//dd[last()]", want: []string{"dd3"}},
+		"position function":             {expr: "//dd[position() > 1]", want: []string{"dd2", "dd3"}},
+		"attribute predicate":           {expr: "//div[@class='nombre']", want: []string{"n1", "n2"}},
+		"attribute exists":              {expr: "//*[@href]/..", want: []string{"v2"}},
+		"dt label paired with dd value": {expr: "//dt[strong='Ring Gauge']/following-sibling::dd[1]", want: []string{"dd2"}},
+		"label paired with value": {
+			expr: "//div[contains(@class, 'nombre')][normalize-space(.)='Brand']/following-sibling::div[1]",
+			want: []string{"v2"},
+		},
+		"preceding sibling": {expr: "//dd[.='52']/preceding-sibling::dt[1]", want: []string{"dt2"}},
+		"ancestor":          {expr: "//strong[.='Length']/ancestor::div", want: []string{}},
+		"ancestor by class": {
+			expr: "//a/ancestor::div[contains(@class, 'valor')]", want: []string{"v2"},
+		},
+		"parent":             {expr: "//a/parent::div", want: []string{"v2"}},
+		"union":              {expr: "//dt[1] | //dd[1]", want: []string{"dt1", "dd1"}},
+		"following":          {expr: "//dd[3]/following::div[@id]", want: []string{"n1", "v1", "n2", "v2"}},
+		"preceding":          {expr: "//div[@id='n1']/preceding::dd", want: []string{"dd1", "dd2", "dd3"}},
+		"starts-with and or": {expr: "//dt[starts-with(., 'L') or starts-with(., 'C')]", want: []string{"dt1", "dt3"}},
+		"not":                {expr: "//dd[not(br)]", want: []string{"dd1", "dd2"}},
+		"count":              {expr: "//dl[count(dt) = 3]/dt[1]", want: []string{"dt1"}},
+		"number comparison":  {expr: "//dd[. > 50]", want: []string{"dd2"}},
+		"arithmetic":         {expr: "//dd[position() = 4 div 2 + 1 mod 1]", want: []string{"dd2"}},
+		"no match":           {expr: "//table"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []Node
+			for v := range n.XPath(test.expr) {
+				got = append(got, v)
+			}
+			if len(test.want) == 0 {
+				assert.Empty(t, ids(got))
+				return
+			}
+			assert.Equal(t, test.want, ids(got))
+		})
+	}
+}
+
+func TestXPath_Value(t *testing.T) {
+	n := mustParse(t, xpathDoc)
+
+	tests := map[string]struct {
+		expr   string
+		want   string
+		values []string
+	}{
+		"text of dd": {
+			expr: "//dt[strong='Length']/following-sibling::dd[1]/text()", want: "6.0 in", values: []string{"6.0 in"},
+		},
+		"text nodes": {
+			expr: "//dd[3]/text()", want: "Cuba", values: []string{"Cuba", "Havana"},
+		},
+		"attribute": {
+			expr: "//a/@href", want: "/padron", values: []string{"/padron"},
+		},
+		"string function": {
+			expr: "concat(//dt[1], ': ', substring-after(//dd[1], '6.'))", want: "Length: 0 in",
+		},
+		"count":   {expr: "count(//dt)", want: "3"},
+		"boolean": {expr: "boolean(//comment())", want: "true"},
+		"name":    {expr: "name(//*[@href])", want: "a"},
+		"string-length and substring-before": {
+			expr: "string-length(substring-before(//dd[1], ' '))", want: "3",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			x, err := CompileXPath(test.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, x.Value(n))
+			assert.Equal(t, test.values, x.Values(n))
+		})
+	}
+
+	t.Run("relative to the context node", func(t *testing.T) {
+		dt, ok := MustCompileXPath("//dt[2]").First(n)
+		assert.True(t, ok)
+		assert.Equal(t, "52", MustCompileXPath("following-sibling::dd[1]").Value(dt))
+		assert.Equal(t, "Ring Gauge", MustCompileXPath("string(strong)").Value(dt))
+	})
+}
+
+func TestCompileXPath(t *testing.T) {
+	t.Run("cached", func(t *testing.T) {
+		const expr = "//dt[1]"
+		got, err := CompileXPath(expr)
+		assert.NoError(t, err)
+		assert.Equal(t, expr, got.String())
+		_, ok := compiledXPath.Load(expr)
+		assert.True(t, ok)
+	})
+
+	for _, expr := range []string{
+		"", "//", "//dt[", "//dt[1", "foo::dt", "//dt/unknown()", "count()", "//dt[@", "'unterminated", "$var",
+		"//dt)",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := CompileXPath(expr)
+			var want *SelectorError
+			assert.ErrorAs(t, err, &want)
+		})
+	}
+
+	t.Run("must compile panics", func(t *testing.T) {
+		assert.Panics(t, func() { MustCompileXPath("//dt[") })
+	})
+}
@@ -1,19 +1,32 @@
 package main
 
 import (
-	"cigarsdb/extract/cigarcentury"
-	"cigarsdb/extract/cigargeeks"
-	"cigarsdb/extract/cigarworld"
-	"cigarsdb/extract/noblego"
+	"cigarsdb/extract"
+	_ "cigarsdb/extract/cigarcentury"
+	_ "cigarsdb/extract/cigargeeks"
+	_ "cigarsdb/extract/cigarworld"
+	"cigarsdb/extract/declarative"
+	_ "cigarsdb/extract/noblego"
 	"cigarsdb/storage"
 	"cigarsdb/storage/fs"
+	"cigarsdb/transform"
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	iofs "io/fs"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
-	"sync"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -37,11 +50,27 @@ func main() {
 	}
 
 	var (
-		dumpDir        string
-		limit, pageMin uint
-		pageMax        uint
-		s              string
-		overwriteBulk  bool
+		dumpDir         string
+		limit, pageMin  uint
+		pageMax         uint
+		s               string
+		overwriteBulk   bool
+		listSources     bool
+		resume          bool
+		checkpointPath  string
+		clientCfg       clientConfig
+		recordDir       string
+		changesPath     string
+		failuresPath    string
+		shutdownTimeout time.Duration
+		workers         int
+		specsDir        string
+		structuredData  bool
+		mismatchesPath  string
+		provenance      bool
+		normalize       bool
+		unmappedPath    string
+		conflictsPath   string
 	)
 	flag.StringVar(&s, "i", "", "source")
 	flag.StringVar(&dumpDir, "o", "/tmp", "output directory")
@@ -50,131 +79,426 @@ func main() {
 	flag.UintVar(&pageMax, "page-max", 0, "fetch until this page number is reached")
 	flag.BoolVar(&overwriteBulk, "wbulk", true,
 		"over-write records in bulk for every page of extraction")
+	flag.BoolVar(&listSources, "list", false, "list available sources")
+	flag.StringVar(&specsDir, "specs", "",
+		"directory with the declarative extractor specs in YAML, or JSON to register as sources, disabled if empty")
+	flag.BoolVar(&resume, "resume", false, "resume the run from the checkpoint")
+	flag.StringVar(&checkpointPath, "checkpoint", "",
+		"path to the checkpoint file, defaults to .{{source}}.checkpoint in the output directory")
+	flag.Float64Var(&clientCfg.ratePolicy.RequestsPerSecond, "rps", 1, "max requests per second sent to a host")
+	flag.IntVar(&clientCfg.ratePolicy.Burst, "burst", 2, "max requests sent to a host at once")
+	flag.IntVar(&clientCfg.ratePolicy.MaxInFlight, "max-inflight", 2, "max concurrent requests sent to a host")
+	flag.IntVar(&workers, "workers", extract.DefaultWorkers, "max detail pages fetched concurrently")
+	flag.IntVar(&clientCfg.retryPolicy.MaxRetries, "max-retries", 5,
+		"max retries of the request failed because of the network error, or the status 408, 429 and 5xx")
+	flag.IntVar(&clientCfg.retryPolicy.HostBudget, "retry-budget", 0,
+		"max retries sent to a host during the run, not limited if zero")
+	flag.StringVar(&clientCfg.userAgent, "user-agent", fmt.Sprintf("cigarsdb/%s (+https://github.com/kislerdm/cigarsdb)", version),
+		"user agent to identify the requests, its product token selects the robots.txt rules")
+	flag.StringVar(&clientCfg.cacheDir, "cache-dir", "", "directory to cache the raw responses in, disabled if empty")
+	flag.DurationVar(&clientCfg.cacheTTL, "cache-ttl", 0, "period when the cached response is fresh, never expires if zero")
+	flag.BoolVar(&clientCfg.cacheOnly, "cache-only", false, "serve the responses from the cache only")
+	flag.StringVar(&changesPath, "changes", "",
+		"path to the report of the records which are new, changed, or disappeared since the previous run, "+
+			"defaults to .{{source}}.changes in the output directory")
+	flag.StringVar(&failuresPath, "failures", "",
+		"path to the report of the run's failures, defaults to .{{source}}.failures in the output directory")
+	flag.DurationVar(&shutdownTimeout, "shutdown-timeout", 30*time.Second,
+		"period to let the in-flight requests finish after SIGINT, or SIGTERM before they are cancelled")
+	flag.BoolVar(&structuredData, "structured-data", false,
+		"fill the missing name, brand and price from the schema.org data embedded in the pages, "+
+			"and log the values which contradict it")
+	flag.StringVar(&mismatchesPath, "mismatches", "",
+		"path to the report of the values which contradict the schema.org data embedded in the pages, "+
+			"defaults to .{{source}}.mismatches in the output directory")
+	flag.BoolVar(&provenance, "provenance", false,
+		"store the origin of every field's value: the page, the selector, the raw text, and the fetch time")
+	flag.BoolVar(&normalize, "normalize", false,
+		"normalise the countries, the formats, the tobacco varieties, the strengths, the colours and the shapes "+
+			"before the records are written")
+	flag.StringVar(&unmappedPath, "unmapped", "",
+		"path to the report of the values which could not be normalised, "+
+			"defaults to .{{source}}.unmapped in the output directory")
+	flag.StringVar(&conflictsPath, "conflicts", "",
+		"path to the report of the records' values which contradict each other, e.g., the length in mm and in inches, "+
+			"defaults to .{{source}}.conflicts in the output directory")
+	flag.StringVar(&recordDir, "record", "",
+		"directory to record the responses and the extracted records in as the test fixture, disabled if empty")
 	flag.Parse()
 
+	clientCfg.retryPolicy.InitialDelay = 5 * time.Second
+	clientCfg.retryPolicy.MaxDelay = 5 * time.Minute
+	clientCfg.retryPolicy.Jitter = 0.2
+
 	var logs = slog.New(slog.NewJSONHandler(os.Stdin, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
 
+	if specsDir != "" {
+		if err := declarative.RegisterDir(specsDir, logs); err != nil {
+			logs.Error("could not register the extractor specs", slog.Any("error", err))
+			return
+		}
+	}
+
+	if listSources {
+		showSources()
+		return
+	}
+
 	destination, err := fs.NewClient(dumpDir)
 	if err != nil {
 		logs.Error("could not init the writer", slog.Any("error", err))
 		return
 	}
 
-	source, err := newSource(s, logs, destination)
+	// the signal stops the run from starting new pages, the in-flight requests are cancelled after the grace period
+	interrupted, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(interrupted, func() {
+		logs.Info("shutting down", slog.Duration("grace period", shutdownTimeout))
+	})
+	ctx, cancel := withGracePeriod(interrupted, shutdownTimeout)
+	defer cancel()
+
+	changes, err := fs.NewChangeDetector(ctx, *destination)
+	if err != nil {
+		logs.Error("could not index the records of the previous run", slog.Any("error", err))
+		return
+	}
+
+	checkpointPath = cmp.Or(checkpointPath, filepath.Join(dumpDir, "."+s+".checkpoint"))
+	checkpoint, err := newCheckpoint(s, checkpointPath, resume)
+	if err != nil {
+		logs.Error("could not read the checkpoint", slog.Any("error", err))
+		return
+	}
+	var writer storage.Writer = checkpoint.Writer(changes)
+	if normalize {
+		pipeline := transform.NewPipeline(transform.Defaults()...)
+		writer = pipeline.Writer(writer)
+		defer func() {
+			reportUnmapped(pipeline.Unmapped(), cmp.Or(unmappedPath, filepath.Join(dumpDir, "."+s+".unmapped")), logs)
+			reportConflicts(pipeline.Conflicts(),
+				cmp.Or(conflictsPath, filepath.Join(dumpDir, "."+s+".conflicts")), logs)
+		}()
+	}
+
+	runStart := time.Now().UTC()
+	defer func() {
+		reportFailures(extract.NewFailureReport(checkpoint.FailuresSince(runStart)),
+			cmp.Or(failuresPath, filepath.Join(dumpDir, "."+s+".failures")), logs)
+	}()
+
+	httpClient, err := newClient(clientCfg, logs)
+	if err != nil {
+		logs.Error("could not initialise the http client", slog.Any("error", err))
+		return
+	}
+
+	var recorder *extract.RecordingClient
+	if recordDir != "" {
+		if recorder, err = extract.NewRecordingClient(httpClient, recordDir, s); err != nil {
+			logs.Error("could not initialise the recording client", slog.Any("error", err))
+			return
+		}
+		httpClient = recorder
+		defer func() {
+			if err := recorder.Save(); err != nil {
+				logs.Error("could not save the fixture", slog.Any("error", err), slog.String("path", recordDir))
+			}
+		}()
+	}
+
+	var structured *extract.StructuredData
+	if structuredData {
+		structured = extract.NewStructuredData(logs)
+		httpClient = structured.Client(httpClient)
+		defer func() {
+			reportMismatches(structured.Mismatches(),
+				cmp.Or(mismatchesPath, filepath.Join(dumpDir, "."+s+".mismatches")), logs)
+		}()
+	}
+
+	var prov *extract.Provenance
+	if provenance {
+		prov = extract.NewProvenance(s, version)
+		httpClient = prov.Client(httpClient)
+	}
+
+	metadata := extract.NewMetadata(s, version)
+	httpClient = metadata.Client(httpClient)
+
+	source, err := newSource(s, logs, httpClient, writer, checkpoint, workers, prov, metadata)
 	if err != nil {
 		logs.Error("could not initialise the source fetching client", slog.Any("error", err))
 		return
 	}
+	if structured != nil {
+		source = structured.Reader(source)
+	}
 
 	page := pageMin
-	ctx := context.Background()
-	for page > 0 {
+	if resume {
+		if page <= checkpoint.LastCompletedPage {
+			page = checkpoint.LastCompletedPage + 1
+		}
+		if pending := checkpoint.Pending(); checkpoint.Page == page && len(pending) > 0 {
+			logs.Info("retry failed", slog.Uint64("page", uint64(page)), slog.Int("count", len(pending)))
+			// the source reported the next page once it found the detail pages
+			nextPage := checkpoint.NextPage
+			if err = retryPending(ctx, source, writer, checkpoint, pending); err != nil {
+				logs.Error("error fetching data", slog.Any("error", err), slog.Uint64("page", uint64(page)))
+				checkpoint.Fail(page, err)
+				saveCheckpoint(checkpoint, checkpointPath, logs)
+				return
+			}
+			checkpoint.Complete(page)
+			saveCheckpoint(checkpoint, checkpointPath, logs)
+			page = nextPage
+		}
+	}
+
+	for page > 0 && interrupted.Err() == nil {
 		logs.Info("start fetching", slog.Uint64("page", uint64(page)))
+		checkpoint.Start(page)
+		saveCheckpoint(checkpoint, checkpointPath, logs)
 
 		rec, nextPage, err := source.ReadBulk(ctx, limit, page)
+		checkpoint.Next(nextPage)
 		if err != nil {
 			logs.Error("error fetching data", slog.Any("error", err), slog.Uint64("page", uint64(page)))
+			// persist the records extracted before the failure to retry only the failed pages on resume
+			if rec = slices.DeleteFunc(rec, storage.Record.IsEmpty); overwriteBulk && len(rec) > 0 {
+				if _, er := writer.Write(context.WithoutCancel(ctx), rec); er != nil {
+					logs.Error("error persisting the data", slog.Any("error", er),
+						slog.Uint64("page", uint64(page)))
+				}
+			}
+			checkpoint.Fail(page, err)
+			saveCheckpoint(checkpoint, checkpointPath, logs)
 			return
 		}
 
+		if recorder != nil {
+			recorder.RecordPage(page, limit, nextPage, rec)
+		}
+
 		if len(rec) > 0 {
 			logs.Info("end fetching", slog.Uint64("page", uint64(page)))
 
-			page = nextPage
 			if overwriteBulk {
-				_, err = destination.Write(ctx, rec)
+				// the empty records are not persisted as on failure
+				_, err = writer.Write(context.WithoutCancel(ctx), slices.DeleteFunc(slices.Clone(rec), storage.Record.IsEmpty))
 				if err != nil {
+					// the page is extracted again on resume
 					logs.Error("error persisting the data", slog.Any("error", err),
 						slog.Uint64("page", uint64(page)))
+					checkpoint.Fail(page, err)
+					saveCheckpoint(checkpoint, checkpointPath, logs)
+					return
 				}
 			}
+			checkpoint.Complete(page)
+			page = nextPage
 
 		} else {
 			delay := 5 * time.Second
 			logs.Error("cool-off", slog.Duration("period", delay))
-			time.Sleep(delay)
+			select {
+			case <-time.After(delay):
+			case <-interrupted.Done():
+			}
 		}
+		saveCheckpoint(checkpoint, checkpointPath, logs)
 
 		if pageMax > 0 && page >= pageMax {
 			break
 		}
-		// delay to prevent denial of server
-		time.Sleep(5 * time.Second)
 	}
+
+	// the records which were not extracted disappeared only if the run covered all pages of the source
+	complete := page == 0 && pageMin <= 1 && !resume
+	reportChanges(changes.Changes(func(u string) bool {
+		return complete && sameHost(u, sourceURL(s))
+	}), cmp.Or(changesPath, filepath.Join(dumpDir, "."+s+".changes")), logs)
 }
 
-func newSource(s string, logs *slog.Logger, writer storage.Writer) (source storage.Reader, err error) {
-	c := newHTTPClient(6*time.Second, 5*time.Second, 5)
+// withGracePeriod returns the context which is cancelled once the grace period passes after the parent is done.
+func withGracePeriod(parent context.Context, grace time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	stop := context.AfterFunc(parent, func() {
+		time.AfterFunc(grace, cancel)
+	})
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
 
-	switch s {
-	case "noblego":
-		source = noblego.Client{HTTPClient: c}
-	case "cigarworld":
-		source = cigarworld.Client{HTTPClient: c, Dumper: writer, Logs: logs}
-	case "cigargeeks":
-		source = cigargeeks.Client{HTTPClient: c, Dumper: writer, Logs: logs}
-	case "cigarcentury":
-		source = cigarcentury.Client{HTTPClient: c, Dumper: writer, Logs: logs}
+func reportChanges(c fs.Changes, path string, logs *slog.Logger) {
+	logs.Info("changes since the previous run",
+		slog.Int("new", len(c.New)),
+		slog.Int("changed", len(c.Changed)),
+		slog.Int("disappeared", len(c.Disappeared)),
+		slog.Int("unchanged", c.Unchanged),
+	)
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, b, 0640)
+	}
+	if err != nil {
+		logs.Error("could not save the changes report", slog.Any("error", err), slog.String("path", path))
+	}
+}
 
-	default:
-		err = fmt.Errorf("data source is unknown")
+func reportFailures(r extract.FailureReport, path string, logs *slog.Logger) {
+	var attrs = []any{slog.Int("total", r.Total)}
+	for _, kind := range slices.Sorted(maps.Keys(r.ByKind)) {
+		attrs = append(attrs, slog.Int(string(kind), r.ByKind[kind]))
+	}
+	logs.Info("failures of the run", attrs...)
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, b, 0640)
+	}
+	if err != nil {
+		logs.Error("could not save the failures report", slog.Any("error", err), slog.String("path", path))
 	}
-	return source, err
 }
 
-type httpClient struct {
-	InitialDelay time.Duration
-	Backoff      time.Duration
-	MaxRetries   uint8
-	attempt      uint8
-	mu           *sync.Mutex
-	c            *http.Client
+func reportUnmapped(u []transform.UnmappedCount, path string, logs *slog.Logger) {
+	logs.Info("values which could not be normalised", slog.Int("total", len(u)))
+	b, err := json.MarshalIndent(u, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, b, 0640)
+	}
+	if err != nil {
+		logs.Error("could not save the unmapped values report", slog.Any("error", err), slog.String("path", path))
+	}
 }
 
-func (h httpClient) Get(url string) (resp *http.Response, err error) {
-	return h.work(func() (*http.Response, error) {
-		return h.c.Get(url)
-	})
+func reportConflicts(c []transform.Conflict, path string, logs *slog.Logger) {
+	logs.Info("values which contradict each other", slog.Int("total", len(c)))
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, b, 0640)
+	}
+	if err != nil {
+		logs.Error("could not save the conflicts report", slog.Any("error", err), slog.String("path", path))
+	}
 }
 
-func (h httpClient) Do(req *http.Request) (resp *http.Response, err error) {
-	return h.work(func() (*http.Response, error) {
-		return h.c.Do(req)
-	})
+func reportMismatches(m []extract.Mismatch, path string, logs *slog.Logger) {
+	logs.Info("values which contradict the structured data", slog.Int("total", len(m)))
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, b, 0640)
+	}
+	if err != nil {
+		logs.Error("could not save the mismatches report", slog.Any("error", err), slog.String("path", path))
+	}
+}
+
+func sourceURL(name string) string {
+	s, _ := extract.Lookup(name)
+	return s.BaseURL
+}
+
+func sameHost(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	return errA == nil && errB == nil && ua.Host == ub.Host
+}
+
+func newCheckpoint(source, path string, resume bool) (*extract.Checkpoint, error) {
+	var (
+		o   = extract.NewCheckpoint(source)
+		err error
+	)
+	if resume {
+		var c *extract.Checkpoint
+		switch c, err = extract.LoadCheckpoint(path); {
+		case errors.Is(err, iofs.ErrNotExist):
+			err = nil
+		case err == nil && c.Source != source:
+			err = fmt.Errorf("checkpoint %s belongs to the source %s", path, c.Source)
+		case err == nil:
+			o = c
+		}
+	}
+	return o, err
 }
 
-func (h httpClient) work(fn func() (*http.Response, error)) (resp *http.Response, err error) {
-	for h.attempt < h.MaxRetries {
-		if resp, err = fn(); err == nil {
-			if resp.StatusCode == http.StatusTooManyRequests {
-				h.mu.Lock()
-				delay := time.Duration(h.InitialDelay.Nanoseconds() + h.Backoff.Nanoseconds()*int64(h.attempt))
-				h.attempt++
-				h.mu.Unlock()
-				time.Sleep(delay)
-
-			} else {
-				h.mu.Lock()
-				h.attempt = 0 //nolint:staticcheck //zeroed value to restart counter after the first OK response
-				h.mu.Unlock()
-				break
+func saveCheckpoint(c *extract.Checkpoint, path string, logs *slog.Logger) {
+	if err := c.Save(path); err != nil {
+		logs.Error("could not save the checkpoint", slog.Any("error", err), slog.String("path", path))
+	}
+}
+
+// retryPending fetches the detail pages which records were not persisted during the interrupted run.
+func retryPending(ctx context.Context, source storage.Reader, writer storage.Writer, c *extract.Checkpoint,
+	urls []string) error {
+	var err error
+	for _, u := range urls {
+		rec, er := source.Read(ctx, u)
+		c.Track(u, er)
+		switch {
+		case errors.As(er, new(*extract.NotACigarError)):
+			// the checkpoint marks the page as done
+		case er != nil:
+			err = errors.Join(err, fmt.Errorf("error reading details using %s: %w", u, er))
+		case rec.IsEmpty():
+			c.Done(u)
+		default:
+			if _, er = writer.Write(ctx, []storage.Record{rec}); er != nil {
+				err = errors.Join(err, fmt.Errorf("error persisting the record from %s: %w", u, er))
 			}
 		}
 	}
-	if h.attempt == h.MaxRetries {
-		err = fmt.Errorf("http client reached attempts limit: %d", h.MaxRetries)
+	return err
+}
+
+func showSources() {
+	for _, s := range extract.Sources() {
+		_, _ = fmt.Fprintf(os.Stdout, "%s\t%s\tlanguages: %s\n", s.Name, s.BaseURL, strings.Join(s.Languages, ","))
 	}
-	return resp, err
 }
 
-func newHTTPClient(initialDelay time.Duration, backoff time.Duration, maxRetries uint8) httpClient {
-	return httpClient{
-		InitialDelay: initialDelay,
-		Backoff:      backoff,
-		MaxRetries:   maxRetries,
-		mu:           new(sync.Mutex),
-		c:            http.DefaultClient,
+// registerSpecs registers the sources defined by the declarative extractor specs found in the directory.
+func newSource(s string, logs *slog.Logger, c extract.HTTPClient, writer storage.Writer, tracker extract.Tracker,
+	workers int, provenance *extract.Provenance, metadata *extract.Metadata) (storage.Reader, error) {
+	return extract.NewReader(s, extract.Config{
+		HTTPClient: c,
+		Dumper:     writer,
+		Logs:       logs,
+		Tracker:    tracker,
+		Workers:    workers,
+		Provenance: provenance,
+		Metadata:   metadata,
+	})
+}
+
+// clientConfig defines the configuration of the http client shared by the sources.
+type clientConfig struct {
+	ratePolicy  extract.RatePolicy
+	retryPolicy extract.RetryPolicy
+	userAgent   string
+	cacheDir    string
+	cacheTTL    time.Duration
+	cacheOnly   bool
+}
+
+func newClient(cfg clientConfig, logs *slog.Logger) (extract.HTTPClient, error) {
+	// every attempt of the retrying client follows robots.txt and is throttled to prevent denial of server,
+	// the unavailable robots.txt is fetched again by the next attempt
+	var c extract.HTTPClient = extract.NewRetryClient(
+		extract.NewRobotsClient(extract.NewRateLimitedClient(http.DefaultClient, cfg.ratePolicy), cfg.userAgent),
+		cfg.retryPolicy, logs)
+
+	var err error
+	if cfg.cacheDir != "" {
+		c, err = extract.NewCacheClient(c, cfg.cacheDir, cfg.cacheTTL, cfg.cacheOnly)
 	}
+	return c, err
 }
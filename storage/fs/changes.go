@@ -0,0 +1,127 @@
+package fs
+
+import (
+	"cigarsdb/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Index reads the checksum of every stored record by the record's URL.
+func (c Client) Index(ctx context.Context) (map[string]string, error) {
+	var o = make(map[string]string)
+	err := fs.WalkDir(os.DirFS(c.Path), ".", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(p, ".json") {
+			var r storage.Record
+			if r, err = c.Read(ctx, strings.TrimSuffix(p, ".json")); err == nil && r.URL != "" {
+				var sum string
+				if sum, err = recordChecksum(r); err == nil {
+					o[r.URL] = sum
+				}
+			}
+		}
+		return err
+	})
+	return o, err
+}
+
+// recordChecksum calculates the checksum of the record's data,
+// the provenance and the metadata are excluded because the fetch time differs between the runs.
+func recordChecksum(r storage.Record) (string, error) {
+	r.Provenance = nil
+	r.Metadata = nil
+	b, err := encode(r)
+	if err != nil {
+		return "", err
+	}
+	return checksum(b), nil
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Changes defines the URLs of the records which differ from the previous run.
+type Changes struct {
+	New         []string `json:"new,omitempty"`
+	Changed     []string `json:"changed,omitempty"`
+	Disappeared []string `json:"disappeared,omitempty"`
+	Unchanged   int      `json:"unchanged"`
+}
+
+// ChangeDetector wraps the Client to compare the written records with the records stored before the run.
+type ChangeDetector struct {
+	Client
+
+	previous map[string]string
+	mu       *sync.Mutex
+	current  map[string]string
+}
+
+// NewChangeDetector indexes the records stored by the client to detect the changes made by the run.
+func NewChangeDetector(ctx context.Context, c Client) (*ChangeDetector, error) {
+	var o *ChangeDetector
+	previous, err := c.Index(ctx)
+	if err == nil {
+		o = &ChangeDetector{
+			Client:   c,
+			previous: previous,
+			mu:       new(sync.Mutex),
+			current:  make(map[string]string),
+		}
+	}
+	return o, err
+}
+
+func (d *ChangeDetector) Write(ctx context.Context, r []storage.Record) ([]string, error) {
+	ids, err := d.Client.Write(ctx, r)
+	if err == nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		for _, el := range r {
+			if el.URL == "" {
+				continue
+			}
+			var sum string
+			if sum, err = recordChecksum(el); err != nil {
+				break
+			}
+			d.current[el.URL] = sum
+		}
+	}
+	return ids, err
+}
+
+// Changes compares the records written during the run with the records stored before the run.
+// The stored record is reported as disappeared if it was not written during the run and inScope returns true for its URL,
+// e.g., if the run covered all pages of the record's source.
+func (d *ChangeDetector) Changes(inScope func(url string) bool) Changes {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var o Changes
+	for _, u := range slices.Sorted(maps.Keys(d.current)) {
+		switch prev, ok := d.previous[u]; {
+		case !ok:
+			o.New = append(o.New, u)
+		case prev != d.current[u]:
+			o.Changed = append(o.Changed, u)
+		default:
+			o.Unchanged++
+		}
+	}
+	if inScope != nil {
+		for _, u := range slices.Sorted(maps.Keys(d.previous)) {
+			if _, ok := d.current[u]; !ok && inScope(u) {
+				o.Disappeared = append(o.Disappeared, u)
+			}
+		}
+	}
+	return o
+}
@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"cigarsdb/storage"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeDetector(t *testing.T) {
+	ctx := context.TODO()
+	c, err := NewClient(t.TempDir())
+	assert.NoError(t, err)
+
+	_, err = c.Write(ctx, []storage.Record{
+		{Name: "foo", URL: "https://example.com/foo"},
+		{Name: "bar", URL: "https://example.com/bar", Price: 10},
+		{Name: "baz", URL: "https://example.com/baz"},
+		{Name: "qux", URL: "https://example.org/qux"},
+		{Name: "corge", URL: "https://example.com/corge", Provenance: map[string]storage.Provenance{
+			"Name": {Raw: "corge", FetchedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}},
+	})
+	assert.NoError(t, err)
+
+	d, err := NewChangeDetector(ctx, *c)
+	assert.NoError(t, err)
+
+	unchanged := storage.Record{Name: "foo", URL: "https://example.com/foo"}
+	p := c.filePath(c.newID(unchanged))
+	past := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(p, past, past))
+
+	_, err = d.Write(ctx, []storage.Record{
+		unchanged,
+		{Name: "bar", URL: "https://example.com/bar", Price: 11},
+		{Name: "quux", URL: "https://example.com/quux"},
+		{Name: "sampler"},
+		{Name: "corge", URL: "https://example.com/corge", Provenance: map[string]storage.Provenance{
+			"Name": {Raw: "corge", FetchedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		}},
+	})
+	assert.NoError(t, err)
+
+	t.Run("unchanged record is not rewritten", func(t *testing.T) {
+		info, err := os.Stat(p)
+		assert.NoError(t, err)
+		assert.True(t, info.ModTime().Equal(past))
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		got := d.Changes(func(url string) bool {
+			return url != "https://example.org/qux"
+		})
+		assert.Equal(t, Changes{
+			New:         []string{"https://example.com/quux"},
+			Changed:     []string{"https://example.com/bar"},
+			Disappeared: []string{"https://example.com/baz"},
+			Unchanged:   2,
+		}, got)
+	})
+
+	t.Run("nothing disappears out of scope", func(t *testing.T) {
+		assert.Empty(t, d.Changes(nil).Disappeared)
+	})
+}
@@ -2,6 +2,7 @@
 package fs
 
 import (
+	"bytes"
 	"cigarsdb/storage"
 	"context"
 	"crypto/sha1"
@@ -12,6 +13,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 )
 
 func NewClient(dir string) (c *Client, err error) {
@@ -25,6 +27,10 @@ type Client struct {
 	Path string
 }
 
+// Write stores every record to its own file.
+// The file is not rewritten if the record did not change since it was stored disregarding when it was fetched,
+// e.g., the page revalidated by extract.CacheClient, or if the stored record was fetched after the written one,
+// see storage.Record.FetchedBefore.
 func (c Client) Write(_ context.Context, r []storage.Record) ([]string, error) {
 	var (
 		ids = make([]string, len(r))
@@ -33,19 +39,63 @@ func (c Client) Write(_ context.Context, r []storage.Record) ([]string, error) {
 	)
 	for i, el := range r {
 		id = c.newID(el)
-		var f io.WriteCloser
-		if f, err = os.OpenFile(c.filePath(id), os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0660); err == nil {
-			if err = json.NewEncoder(f).Encode(el); err == nil {
-				ids[i] = id
-			} else {
-				break
-			}
-			_ = f.Close()
+		var b []byte
+		if b, err = encode(el); err == nil {
+			err = c.writeFile(c.filePath(id), el, b)
+		}
+		if err != nil {
+			break
 		}
+		ids[i] = id
 	}
 	return ids, err
 }
 
+func (c Client) writeFile(p string, r storage.Record, data []byte) error {
+	if v, err := os.ReadFile(p); err == nil {
+		var stored storage.Record
+		switch {
+		case bytes.Equal(v, data):
+			return nil
+		case json.Unmarshal(v, &stored) != nil:
+		case r.FetchedBefore(stored) || sameContent(r, stored):
+			return nil
+		}
+	}
+	return os.WriteFile(p, data, 0660)
+}
+
+// sameContent reports whether the records are equal disregarding the fetch time of the record and its fields.
+func sameContent(a, b storage.Record) bool {
+	x, errX := encode(unstamped(a))
+	y, errY := encode(unstamped(b))
+	return errX == nil && errY == nil && bytes.Equal(x, y)
+}
+
+// unstamped returns the copy of the record without the fetch time.
+func unstamped(r storage.Record) storage.Record {
+	if r.Metadata != nil {
+		m := *r.Metadata
+		m.FetchedAt = time.Time{}
+		r.Metadata = &m
+	}
+	if r.Provenance != nil {
+		prov := make(map[string]storage.Provenance, len(r.Provenance))
+		for k, v := range r.Provenance {
+			v.FetchedAt = time.Time{}
+			prov[k] = v
+		}
+		r.Provenance = prov
+	}
+	return r
+}
+
+func encode(r storage.Record) ([]byte, error) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(r)
+	return buf.Bytes(), err
+}
+
 func (c Client) Read(_ context.Context, id string) (storage.Record, error) {
 	var (
 		r   storage.Record
@@ -6,6 +6,7 @@ import (
 	"context"
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -90,3 +91,43 @@ func Test(t *testing.T) {
 		assert.Equal(t, len(wantBulk), cnt)
 	})
 }
+
+func TestClient_Write_fetchedBefore(t *testing.T) {
+	c, err := NewClient(t.TempDir())
+	assert.NoError(t, err)
+	ctx := context.TODO()
+
+	newRecord := func(price float64, fetchedAt time.Time) storage.Record {
+		return storage.Record{Name: "foo", URL: "https://example.com/foo", Price: price,
+			Metadata: &storage.Metadata{Source: "bar", FetchedAt: fetchedAt, SchemaVersion: storage.SchemaVersion}}
+	}
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ids, err := c.Write(ctx, []storage.Record{newRecord(10, t0)})
+	assert.NoError(t, err)
+
+	t.Run("stored record fetched later is kept", func(t *testing.T) {
+		_, err := c.Write(ctx, []storage.Record{newRecord(9, t0.Add(-time.Hour))})
+		assert.NoError(t, err)
+		got, err := c.Read(ctx, ids[0])
+		assert.NoError(t, err)
+		assert.Equal(t, newRecord(10, t0), got)
+	})
+
+	t.Run("stored record fetched before is overwritten", func(t *testing.T) {
+		_, err := c.Write(ctx, []storage.Record{newRecord(11, t0.Add(time.Hour))})
+		assert.NoError(t, err)
+		got, err := c.Read(ctx, ids[0])
+		assert.NoError(t, err)
+		assert.Equal(t, newRecord(11, t0.Add(time.Hour)), got)
+	})
+
+	t.Run("unchanged record fetched later is not rewritten", func(t *testing.T) {
+		// the page revalidated by the http cache yields the same record with the new fetch time
+		_, err := c.Write(ctx, []storage.Record{newRecord(11, t0.Add(2*time.Hour))})
+		assert.NoError(t, err)
+		got, err := c.Read(ctx, ids[0])
+		assert.NoError(t, err)
+		assert.Equal(t, newRecord(11, t0.Add(time.Hour)), got)
+	})
+}
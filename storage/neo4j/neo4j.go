@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
@@ -48,6 +50,12 @@ func (c Client) Write(ctx context.Context, r []storage.Record) (ids []string, er
 			err = fmt.Errorf("could not convert record %d to neo4j record: %w", i, err)
 			break
 		}
+		// the metadata is stored as the node's properties because the nested maps cannot be stored,
+		// the stored node is not updated with the record fetched before it
+		if m, ok := records[i]["metadata"].(map[string]any); ok {
+			delete(records[i], "metadata")
+			maps.Copy(records[i], m)
+		}
 	}
 
 	if err == nil {
@@ -55,7 +63,9 @@ func (c Client) Write(ctx context.Context, r []storage.Record) (ids []string, er
 
 			return tx.Run(ctx, `WITH $records AS records, apoc.date.currentTimestamp() AS now
 UNWIND records AS rec
-MERGE (n:CigarRaw{identifier:apoc.map.get(rec, "url", "", false)}) SET n += rec
+MERGE (n:CigarRaw{identifier:apoc.map.get(rec, "url", "", false)})
+WITH n, rec, now WHERE n.fetchedAt IS NULL OR rec.fetchedAt IS NULL OR n.fetchedAt <= rec.fetchedAt
+SET n += rec
 , n.createdAt = apoc.map.get(n, "createdAt", now, false)
 , n.updatedAt = now
 `, map[string]interface{}{
@@ -89,6 +99,10 @@ func fromRecord(rec any) (map[string]any, error) {
 			fieldType := t.Field(i)
 			key := strings.Split(fieldType.Tag.Get("json"), ",")[0]
 			fieldVal := v.Field(i)
+			if ts, ok := fieldVal.Interface().(time.Time); ok {
+				o[key] = ts
+				continue
+			}
 			switch fieldVal.Kind() {
 			case reflect.Struct:
 				if o[key], err = fromRecord(fieldVal.Interface()); err != nil {
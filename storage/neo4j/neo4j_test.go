@@ -1,7 +1,9 @@
 package neo4j
 
 import (
+	"cigarsdb/storage"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -55,6 +57,25 @@ func Test_fromRecord(t *testing.T) {
 		"qux": []float64{1.0, 2.0, 3.0},
 	}
 	assert.Equal(t, want, got)
+
+	t.Run("provenance", func(t *testing.T) {
+		got, err := fromRecord(storage.Record{Name: "foo", Provenance: map[string]storage.Provenance{
+			"Name": {Source: "bar", Raw: "foo", FetchedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"Name":{"source":"bar","raw":"foo","fetchedAt":"2025-01-01T00:00:00Z"}}`, got["provenance"])
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		fetchedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		got, err := fromRecord(storage.Record{Name: "foo", Metadata: &storage.Metadata{
+			Source: "bar", FetchedAt: fetchedAt, ExtractorVersion: "v0.1.0", SchemaVersion: 1, HTTPStatus: 200,
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"source": "bar", "fetchedAt": fetchedAt, "extractorVersion": "v0.1.0", "schemaVersion": 1, "httpStatus": 200,
+		}, got["metadata"])
+	})
 }
 
 func pointer[V int64 | int | string](v V) *V {
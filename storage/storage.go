@@ -1,7 +1,10 @@
 // Package storage defines the storage port to persist the data.
 package storage
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type SpecializedRating struct {
 	Who            string  `json:"who"`
@@ -93,12 +96,67 @@ type Record struct {
 	// AdditionalNotes additional info, e.g., barrel-aged
 	AdditionalNotes    *string             `json:"additionalNotes,omitempty"`
 	SpecializedRatings []SpecializedRating `json:"specializedRatings,omitempty"`
+
+	// Provenance the origin of the fields' values, keyed by the field's name, e.g., Diameter.
+	Provenance map[string]Provenance `json:"provenance,omitempty"`
+	// Metadata the record's extraction metadata.
+	Metadata *Metadata `json:"metadata,omitempty"`
 }
 
 func (r Record) IsEmpty() bool {
 	return r.Name == ""
 }
 
+// FetchedBefore reports whether the record's page was fetched before the page of the other record.
+// It is false if the fetch time of either record is unknown.
+func (r Record) FetchedBefore(o Record) bool {
+	return r.Metadata != nil && o.Metadata != nil && !r.Metadata.FetchedAt.IsZero() &&
+		r.Metadata.FetchedAt.Before(o.Metadata.FetchedAt)
+}
+
+// SchemaVersion the version of the Record's schema, it is incremented when the stored data become incompatible.
+const SchemaVersion = 1
+
+// Metadata defines the record's extraction metadata.
+type Metadata struct {
+	// Source the identifier of the data source, e.g., noblego.
+	Source string `json:"source"`
+	// FetchedAt the time when the record's page was fetched.
+	FetchedAt time.Time `json:"fetchedAt"`
+	// ExtractorVersion the version of the tool which extracted the record.
+	ExtractorVersion string `json:"extractorVersion"`
+	// SchemaVersion the version of the record's schema, see SchemaVersion.
+	SchemaVersion int `json:"schemaVersion"`
+	// HTTPStatus the status code of the response with the record's page.
+	HTTPStatus int `json:"httpStatus"`
+}
+
+// SetProvenance sets the origin of the field's value.
+func (r *Record) SetProvenance(field string, p Provenance) {
+	if r.Provenance == nil {
+		r.Provenance = make(map[string]Provenance)
+	}
+	r.Provenance[field] = p
+}
+
+// Provenance defines the origin of the record's field value.
+type Provenance struct {
+	// Source the name of the data source, e.g., noblego.
+	Source string `json:"source,omitempty"`
+	// URL the page the value was read from.
+	URL string `json:"url,omitempty"`
+	// Selector the selector of the html element holding the value.
+	Selector string `json:"selector,omitempty"`
+	// Label the label of the value on the page, e.g., Ring Gauge.
+	Label string `json:"label,omitempty"`
+	// Raw the raw text the value was parsed from.
+	Raw string `json:"raw,omitempty"`
+	// ExtractorVersion the version of the tool which extracted the value.
+	ExtractorVersion string `json:"extractorVersion,omitempty"`
+	// FetchedAt the time when the page was fetched.
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
 type AromaProfileCommunity struct {
 	Weights       map[string]float64 `json:"weights"`
 	NumberOfVotes int                `json:"numberOfVotes"`
@@ -0,0 +1,131 @@
+package dimension
+
+import (
+	"slices"
+	"strings"
+)
+
+// Color defines the wrapper's colour, e.g., "Colorado Claro".
+type Color string
+
+// ColorShade defines the standard scale of the wrapper's colour from 1, candela to 7, oscuro.
+type ColorShade int
+
+const (
+	// ColorUnknown the shade of the unknown colour.
+	ColorUnknown ColorShade = iota
+	ColorCandela
+	ColorClaro
+	ColorColoradoClaro
+	ColorColorado
+	ColorColoradoMaduro
+	ColorMaduro
+	ColorOscuro
+)
+
+var colorLabels = map[ColorShade]string{
+	ColorCandela:        "Candela",
+	ColorClaro:          "Claro",
+	ColorColoradoClaro:  "Colorado Claro",
+	ColorColorado:       "Colorado",
+	ColorColoradoMaduro: "Colorado Maduro",
+	ColorMaduro:         "Maduro",
+	ColorOscuro:         "Oscuro",
+}
+
+// String returns the canonical label of the shade, it is empty for ColorUnknown.
+func (c ColorShade) String() string {
+	return colorLabels[c]
+}
+
+// colorShades maps the colours in Spanish, English and German to the shades.
+var colorShades = map[string]ColorShade{
+	// Spanish
+	"candela":         ColorCandela,
+	"double claro":    ColorCandela,
+	"doble claro":     ColorCandela,
+	"claro claro":     ColorCandela,
+	"verde":           ColorCandela,
+	"claro":           ColorClaro,
+	"colorado claro":  ColorColoradoClaro,
+	"natural":         ColorColoradoClaro,
+	"colorado":        ColorColorado,
+	"rosado":          ColorColorado,
+	"colorado maduro": ColorColoradoMaduro,
+	"maduro":          ColorMaduro,
+	"oscuro":          ColorOscuro,
+	"negro":           ColorOscuro,
+	"maduro oscuro":   ColorOscuro,
+	// English
+	"green":         ColorCandela,
+	"jade":          ColorCandela,
+	"light":         ColorClaro,
+	"light brown":   ColorClaro,
+	"medium brown":  ColorColoradoClaro,
+	"red brown":     ColorColorado,
+	"reddish brown": ColorColorado,
+	"dark brown":    ColorColoradoMaduro,
+	"dark":          ColorMaduro,
+	"very dark":     ColorOscuro,
+	"black":         ColorOscuro,
+	// German
+	"grün":        ColorCandela,
+	"hell":        ColorClaro,
+	"hellbraun":   ColorClaro,
+	"natur":       ColorColoradoClaro,
+	"mittelbraun": ColorColoradoClaro,
+	"rotbraun":    ColorColorado,
+	"dunkelbraun": ColorColoradoMaduro,
+	"dunkel":      ColorMaduro,
+	"sehr dunkel": ColorOscuro,
+	"schwarz":     ColorOscuro,
+}
+
+// Shade returns the colour's shade on the standard scale, or ColorUnknown.
+func (s Color) Shade() ColorShade {
+	return colorShades[colorKey(string(s))]
+}
+
+func (s Color) Convert() string {
+	o, ok := s.Lookup()
+	if !ok {
+		o = strings.TrimSpace(string(s))
+	}
+	return o
+}
+
+// Lookup returns the canonical label of the colour, it returns false if the colour is not known.
+func (s Color) Lookup() (string, bool) {
+	c := s.Shade()
+	return c.String(), c != ColorUnknown
+}
+
+// varietyColors defines the colours which are named in the tobacco varieties, e.g., "Habano Oscuro",
+// the other terms, e.g., "natural", or "dark" are too generic to be inferred from the variety's name.
+var varietyColors = []string{
+	"candela", "double claro", "doble claro", "claro", "colorado claro", "colorado", "colorado maduro", "maduro",
+	"oscuro", "negro", "maduro oscuro",
+}
+
+// ColorFromTobaccoVariety infers the wrapper's colour from the names of its tobacco varieties,
+// e.g., "Connecticut Broadleaf Maduro" is Maduro. The first variety which names the colour is used.
+// It returns false if none of the varieties names the colour.
+func ColorFromTobaccoVariety(varieties ...string) (Color, bool) {
+	for _, v := range varieties {
+		words := strings.Fields(colorKey(v))
+		// the colours of two words are matched first, e.g., "colorado claro" before "claro"
+		for size := 2; size > 0; size-- {
+			for i := 0; i+size <= len(words); i++ {
+				if k := strings.Join(words[i:i+size], " "); slices.Contains(varietyColors, k) {
+					return Color(colorShades[k].String()), true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+func colorKey(s string) string {
+	s = strings.NewReplacer("-", " ", "_", " ", "/", " ").Replace(strings.ToLower(s))
+	return strings.Join(strings.Fields(s), " ")
+}
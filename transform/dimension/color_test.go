@@ -0,0 +1,53 @@
+package dimension
+
+import "testing"
+
+func TestColor(t *testing.T) {
+	tests := []struct {
+		s         Color
+		wantShade ColorShade
+		want      string
+	}{
+		{s: "Colorado Maduro", wantShade: ColorColoradoMaduro, want: "Colorado Maduro"},
+		{s: "colorado-claro", wantShade: ColorColoradoClaro, want: "Colorado Claro"},
+		{s: "Natural", wantShade: ColorColoradoClaro, want: "Colorado Claro"},
+		{s: "Double Claro", wantShade: ColorCandela, want: "Candela"},
+		{s: "Dunkelbraun", wantShade: ColorColoradoMaduro, want: "Colorado Maduro"},
+		{s: " dark ", wantShade: ColorMaduro, want: "Maduro"},
+		{s: "Negro", wantShade: ColorOscuro, want: "Oscuro"},
+		{s: "Shade ", wantShade: ColorUnknown, want: "Shade"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.s), func(t *testing.T) {
+			if got := tt.s.Shade(); got != tt.wantShade {
+				t.Errorf("Shade() = %v, want %v", got, tt.wantShade)
+			}
+			if got := tt.s.Convert(); got != tt.want {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorFromTobaccoVariety(t *testing.T) {
+	tests := []struct {
+		name      string
+		varieties []string
+		want      Color
+		wantOK    bool
+	}{
+		{name: "maduro", varieties: []string{"Connecticut Broadleaf Maduro"}, want: "Maduro", wantOK: true},
+		{name: "two words", varieties: []string{"Colorado Claro"}, want: "Colorado Claro", wantOK: true},
+		{name: "second variety", varieties: []string{"Habano", "San Andres Negro"}, want: "Oscuro", wantOK: true},
+		{name: "generic term", varieties: []string{"Ecuador Natural"}},
+		{name: "no colour", varieties: []string{"Connecticut Shade"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ColorFromTobaccoVariety(tt.varieties...)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ColorFromTobaccoVariety() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
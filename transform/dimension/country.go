@@ -4,46 +4,54 @@ import "strings"
 
 type Country string
 
-func (s Country) Convert() string {
-	lookup := map[string]string{
-		"ecuador":                 "Ecuador",
-		"nicaragua":               "Nicaragua",
-		"honduras":                "Honduras",
-		"dominikanische republik": "Dominican Republic",
-		"kuba":                    "Cuba",
-		"brasilien":               "Brazil",
-		"usa":                     "USA",
-		"mexiko":                  "Mexico",
-		"kamerun":                 "Cameroon",
-		"sumatra":                 "Sumatra",
-		"costa rica":              "Costa Rica",
-		"indonesien":              "Indonesia",
-		"panama":                  "Panama",
-		"indonesia":               "Indonesia",
-		"peru":                    "Peru",
-		"java":                    "Java",
-		"philippinen":             "Philippines",
-		"san andres":              "San Andres",
-		"italien":                 "Italy",
-		"deutschland":             "Germany",
-		"pennsylvania":            "Pennsylvania",
-		"karibik":                 "Caribbean",
-		"kanaren":                 "Canary Islands",
-		"kanarische inseln":       "Canary Islands",
-		"mosambik":                "Mozambique",
-		"kolumbien":               "Colombia",
-		"unbekannt / geheim":      "",
-		"unbekannt":               "",
-		"geheim":                  "",
-		"ohne":                    "",
-	}
+// countries maps the known country names to the English names.
+var countries = map[string]string{
+	"ecuador":                 "Ecuador",
+	"nicaragua":               "Nicaragua",
+	"honduras":                "Honduras",
+	"dominikanische republik": "Dominican Republic",
+	"kuba":                    "Cuba",
+	"brasilien":               "Brazil",
+	"usa":                     "USA",
+	"mexiko":                  "Mexico",
+	"kamerun":                 "Cameroon",
+	"sumatra":                 "Sumatra",
+	"costa rica":              "Costa Rica",
+	"indonesien":              "Indonesia",
+	"panama":                  "Panama",
+	"indonesia":               "Indonesia",
+	"peru":                    "Peru",
+	"java":                    "Java",
+	"philippinen":             "Philippines",
+	"san andres":              "San Andres",
+	"italien":                 "Italy",
+	"deutschland":             "Germany",
+	"pennsylvania":            "Pennsylvania",
+	"karibik":                 "Caribbean",
+	"kanaren":                 "Canary Islands",
+	"kanarische inseln":       "Canary Islands",
+	"mosambik":                "Mozambique",
+	"kolumbien":               "Colombia",
+	"unbekannt / geheim":      "",
+	"unbekannt":               "",
+	"geheim":                  "",
+	"ohne":                    "",
+}
 
-	var o string
-	tmp := strings.ToLower(string(s))
-	tmp = strings.NewReplacer("_", " ", "-", " ").Replace(tmp)
-	o, ok := lookup[tmp]
+func (s Country) Convert() string {
+	o, ok := s.Lookup()
 	if !ok {
-		o = toCapFirstLetters(tmp)
+		o = toCapFirstLetters(s.key())
 	}
 	return o
 }
+
+// Lookup returns the country's English name, the name is empty if the country is unknown, or secret.
+// It returns false if the country is not known.
+func (s Country) Lookup() (string, bool) {
+	return lookup(countries, s.key())
+}
+
+func (s Country) key() string {
+	return strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(string(s)))
+}
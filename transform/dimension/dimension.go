@@ -4,6 +4,28 @@
 // Note that the Spanish and the singular nouns are used as opposed to English, or German and plural nouns.
 package dimension
 
+import "strings"
+
 type Dimension interface {
 	Convert() string
 }
+
+// Lookup defines the dimension which reports whether the value is known.
+type Lookup interface {
+	Dimension
+	// Lookup returns the canonical value, and false if the value is not known.
+	Lookup() (string, bool)
+}
+
+// lookup returns the canonical value by the lower case key, the canonical values are known as well.
+func lookup(m map[string]string, key string) (string, bool) {
+	o, ok := m[key]
+	if !ok {
+		for _, v := range m {
+			if v != "" && strings.ToLower(v) == key {
+				return v, true
+			}
+		}
+	}
+	return o, ok
+}
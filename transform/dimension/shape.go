@@ -0,0 +1,86 @@
+package dimension
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// MMPerInch the length of the inch in mm.
+	MMPerInch = 25.4
+	// RingPerInch the number of the ring gauge's units per inch.
+	RingPerInch = 64.
+)
+
+// Shape defines the cigar's size, the zero value of the field means it is not known.
+type Shape struct {
+	// Diameter the diameter in mm.
+	Diameter float64
+	// Ring the ring gauge, i.e., the diameter in 1/64 inch.
+	Ring float64
+	// Length the length in mm.
+	Length float64
+	// LengthInch the length in inches.
+	LengthInch float64
+}
+
+// ShapeConflict defines the pair of the shape's fields whose values contradict each other.
+type ShapeConflict struct {
+	Field, Other           string
+	FieldValue, OtherValue float64
+	FieldUnit, OtherUnit   string
+}
+
+func (c ShapeConflict) String() string {
+	return fmt.Sprintf("%v %s contradicts %s %v %s", c.FieldValue, c.FieldUnit, c.Other, c.OtherValue, c.OtherUnit)
+}
+
+// Resolve derives the shape's missing fields from the present ones, e.g., the diameter from the ring gauge.
+// The values are rounded: mm to one decimal, inches to two decimals, the ring gauge to one decimal,
+// the derived ring gauge to the whole number as the gauges are.
+// The present values are kept even if they contradict each other, i.e., the relative difference of the value
+// and the value converted from its counterpart exceeds the tolerance, e.g., 0.05, the conflicts are returned instead.
+func (s Shape) Resolve(tolerance float64) (Shape, []ShapeConflict) {
+	var o []ShapeConflict
+	switch {
+	case s.Diameter > 0 && s.Ring > 0:
+		if differ(s.Diameter, s.Ring/RingPerInch*MMPerInch, tolerance) {
+			o = append(o, ShapeConflict{
+				Field: "Diameter", FieldValue: s.Diameter, FieldUnit: "mm",
+				Other: "Ring", OtherValue: s.Ring, OtherUnit: "ring",
+			})
+		}
+	case s.Diameter > 0:
+		s.Ring = round(s.Diameter/MMPerInch*RingPerInch, 0)
+	case s.Ring > 0:
+		s.Diameter = s.Ring / RingPerInch * MMPerInch
+	}
+
+	switch {
+	case s.Length > 0 && s.LengthInch > 0:
+		if differ(s.Length, s.LengthInch*MMPerInch, tolerance) {
+			o = append(o, ShapeConflict{
+				Field: "Length", FieldValue: s.Length, FieldUnit: "mm",
+				Other: "LengthInch", OtherValue: s.LengthInch, OtherUnit: "inch",
+			})
+		}
+	case s.Length > 0:
+		s.LengthInch = s.Length / MMPerInch
+	case s.LengthInch > 0:
+		s.Length = s.LengthInch * MMPerInch
+	}
+
+	s.Diameter, s.Ring = round(s.Diameter, 1), round(s.Ring, 1)
+	s.Length, s.LengthInch = round(s.Length, 1), round(s.LengthInch, 2)
+	return s, o
+}
+
+// differ reports whether the relative difference of the values exceeds the tolerance.
+func differ(a, b, tolerance float64) bool {
+	return math.Abs(a-b) > tolerance*math.Max(a, b)
+}
+
+func round(v float64, decimals int) float64 {
+	p := math.Pow10(decimals)
+	return math.Round(v*p) / p
+}
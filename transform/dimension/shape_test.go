@@ -0,0 +1,51 @@
+package dimension
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShape_Resolve(t *testing.T) {
+	tests := []struct {
+		name          string
+		s             Shape
+		want          Shape
+		wantConflicts []ShapeConflict
+	}{
+		{
+			name: "mm",
+			s:    Shape{Diameter: 21, Length: 156},
+			want: Shape{Diameter: 21, Ring: 53, Length: 156, LengthInch: 6.14},
+		},
+		{
+			name: "inches and ring gauge",
+			s:    Shape{Ring: 52, LengthInch: 6.125},
+			want: Shape{Diameter: 20.6, Ring: 52, Length: 155.6, LengthInch: 6.13},
+		},
+		{
+			name: "within tolerance",
+			s:    Shape{Diameter: 20.5, Ring: 52, Length: 156, LengthInch: 6.125},
+			want: Shape{Diameter: 20.5, Ring: 52, Length: 156, LengthInch: 6.13},
+		},
+		{
+			name: "contradiction",
+			s:    Shape{Diameter: 15, Ring: 52, Length: 127},
+			want: Shape{Diameter: 15, Ring: 52, Length: 127, LengthInch: 5},
+			wantConflicts: []ShapeConflict{{
+				Field: "Diameter", FieldValue: 15, FieldUnit: "mm", Other: "Ring", OtherValue: 52, OtherUnit: "ring",
+			}},
+		},
+		{name: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := tt.s.Resolve(0.05)
+			if got != tt.want {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(conflicts, tt.wantConflicts) {
+				t.Errorf("Resolve() conflicts = %v, want %v", conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package dimension
+
+import "strings"
+
+// Strength defines the label of the cigar's strength, or of the flavour's strength, e.g., "Mild bis Medium".
+type Strength string
+
+// StrengthLevel defines the ordinal scale of the strength from 1, mild to 5, full.
+type StrengthLevel int
+
+const (
+	// StrengthUnknown the level of the unknown label.
+	StrengthUnknown StrengthLevel = iota
+	StrengthMild
+	StrengthMildMedium
+	StrengthMedium
+	StrengthMediumFull
+	StrengthFull
+)
+
+var strengthLabels = map[StrengthLevel]string{
+	StrengthMild:       "Mild",
+	StrengthMildMedium: "Mild-Medium",
+	StrengthMedium:     "Medium",
+	StrengthMediumFull: "Medium-Full",
+	StrengthFull:       "Full",
+}
+
+// String returns the canonical label of the level, it is empty for StrengthUnknown.
+func (l StrengthLevel) String() string {
+	return strengthLabels[l]
+}
+
+// strengthLevels maps the labels used by the sources to the levels.
+// The key is normalised, i.e., the ranges are joined with "-", see Strength.key.
+var strengthLevels = map[string]StrengthLevel{
+	// English: cigargeeks, cigarcentury
+	"very mild":     StrengthMild,
+	"mild":          StrengthMild,
+	"light":         StrengthMild,
+	"mild-medium":   StrengthMildMedium,
+	"light-medium":  StrengthMildMedium,
+	"medium":        StrengthMedium,
+	"medium-full":   StrengthMediumFull,
+	"medium-strong": StrengthMediumFull,
+	"full":          StrengthFull,
+	"full-bodied":   StrengthFull,
+	"strong":        StrengthFull,
+	"very strong":   StrengthFull,
+	"extra strong":  StrengthFull,
+	// German: noblego, cigarworld
+	"sehr mild":      StrengthMild,
+	"leicht":         StrengthMild,
+	"mild-mittel":    StrengthMildMedium,
+	"mittel":         StrengthMedium,
+	"mittelkräftig":  StrengthMediumFull,
+	"medium-kräftig": StrengthMediumFull,
+	"medium-stark":   StrengthMediumFull,
+	"mittel-kräftig": StrengthMediumFull,
+	"mittel-stark":   StrengthMediumFull,
+	"kräftig":        StrengthFull,
+	"stark":          StrengthFull,
+	"sehr kräftig":   StrengthFull,
+	"sehr stark":     StrengthFull,
+	// Spanish
+	"suave":        StrengthMild,
+	"suave-medio":  StrengthMildMedium,
+	"medio":        StrengthMedium,
+	"medio-fuerte": StrengthMediumFull,
+	"fuerte":       StrengthFull,
+}
+
+// Level returns the label's level on the ordinal scale, or StrengthUnknown.
+func (s Strength) Level() StrengthLevel {
+	return strengthLevels[s.key()]
+}
+
+func (s Strength) Convert() string {
+	o, ok := s.Lookup()
+	if !ok {
+		o = strings.TrimSpace(string(s))
+	}
+	return o
+}
+
+// Lookup returns the canonical label of the strength, it returns false if the label is not known.
+func (s Strength) Lookup() (string, bool) {
+	l := s.Level()
+	return l.String(), l != StrengthUnknown
+}
+
+// key normalises the label, e.g., "Mild bis Medium" and "Mild to Medium" become "mild-medium".
+// The flavour's strength is labeled as the strength followed by "aromatisch" by noblego, e.g., "Medium-aromatisch".
+func (s Strength) key() string {
+	o := strings.ToLower(string(s))
+	o = strings.NewReplacer("aromatisch", "", " bis ", "-", " to ", "-", "/", "-", "–", "-", "_", " ").Replace(o)
+	var els []string
+	for _, el := range strings.Split(o, "-") {
+		if el = strings.Join(strings.Fields(el), " "); el != "" {
+			els = append(els, el)
+		}
+	}
+	return strings.Join(els, "-")
+}
@@ -0,0 +1,31 @@
+package dimension
+
+import "testing"
+
+func TestStrength(t *testing.T) {
+	tests := []struct {
+		s         Strength
+		wantLevel StrengthLevel
+		want      string
+	}{
+		{s: "Medium", wantLevel: StrengthMedium, want: "Medium"},
+		{s: "Sehr mild", wantLevel: StrengthMild, want: "Mild"},
+		{s: "Mild bis Medium", wantLevel: StrengthMildMedium, want: "Mild-Medium"},
+		{s: "Mild to Medium", wantLevel: StrengthMildMedium, want: "Mild-Medium"},
+		{s: "Medium-kräftig", wantLevel: StrengthMediumFull, want: "Medium-Full"},
+		{s: "Medium-aromatisch", wantLevel: StrengthMedium, want: "Medium"},
+		{s: " Stark ", wantLevel: StrengthFull, want: "Full"},
+		{s: "Medium / Full", wantLevel: StrengthMediumFull, want: "Medium-Full"},
+		{s: "Leicht Süß ", wantLevel: StrengthUnknown, want: "Leicht Süß"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.s), func(t *testing.T) {
+			if got := tt.s.Level(); got != tt.wantLevel {
+				t.Errorf("Level() = %v, want %v", got, tt.wantLevel)
+			}
+			if got := tt.s.Convert(); got != tt.want {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
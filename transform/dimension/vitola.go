@@ -0,0 +1,165 @@
+package dimension
+
+import (
+	"math"
+	"slices"
+	"strings"
+)
+
+// Vitola defines the cigar's standard size.
+type Vitola struct {
+	// Galera the vitola de galera, i.e., the factory name of the size, e.g., Mareva.
+	Galera string
+	// Commercial the commercial name of the size, e.g., Petit Corona.
+	Commercial string
+	// Length the length in mm.
+	Length float64
+	// Ring the ring gauge.
+	Ring float64
+	// Figurado the shaped cigar, e.g., Pirámide, which is not classified by the size only, see ClassifyVitola.
+	Figurado bool
+}
+
+// Name returns the vitola de galera, or the commercial name if the size has no vitola de galera.
+func (v Vitola) Name() string {
+	if v.Galera != "" {
+		return v.Galera
+	}
+	return v.Commercial
+}
+
+// Vitolas defines the standard sizes, the vitolas de galera of the Cuban factories and the commercial sizes.
+var Vitolas = []Vitola{
+	{Galera: "Entreacto", Commercial: "Demi Tasse", Length: 100, Ring: 30},
+	{Galera: "Perla", Commercial: "Half Corona", Length: 102, Ring: 40},
+	{Galera: "Petit Robusto", Commercial: "Petit Robusto", Length: 102, Ring: 50},
+	{Galera: "Minuto", Commercial: "Half Corona", Length: 110, Ring: 42},
+	{Galera: "Laguito No. 3", Commercial: "Cigarillo", Length: 115, Ring: 26},
+	{Galera: "Cadete", Commercial: "Short Panatela", Length: 115, Ring: 36},
+	{Galera: "Franciscano", Commercial: "Petit Corona", Length: 116, Ring: 40},
+	{Galera: "Panetela", Commercial: "Panatela", Length: 117, Ring: 34},
+	{Galera: "Robusto", Commercial: "Robusto", Length: 124, Ring: 50},
+	{Galera: "Placera", Commercial: "Short Panatela", Length: 125, Ring: 34},
+	{Galera: "Hermoso No. 4", Commercial: "Corona Extra", Length: 127, Ring: 48},
+	{Galera: "Mareva", Commercial: "Petit Corona", Length: 129, Ring: 42},
+	{Galera: "Edmundo", Commercial: "Robusto Extra", Length: 135, Ring: 52},
+	{Galera: "Campana", Commercial: "Belicoso", Length: 140, Ring: 52, Figurado: true},
+	{Galera: "Corona", Commercial: "Corona", Length: 142, Ring: 42},
+	{Galera: "Corona Gorda", Commercial: "Corona Gorda", Length: 143, Ring: 46},
+	{Galera: "Cristal", Commercial: "Corona", Length: 143, Ring: 40},
+	{Galera: "Cañonazo", Commercial: "Toro", Length: 150, Ring: 52},
+	{Galera: "Laguito No. 2", Commercial: "Panatela", Length: 152, Ring: 38},
+	{Commercial: "Gordo", Length: 152, Ring: 60},
+	{Galera: "Corona Grande", Commercial: "Long Corona", Length: 155, Ring: 42},
+	{Galera: "Pirámide", Commercial: "Torpedo", Length: 156, Ring: 52, Figurado: true},
+	{Galera: "Delicioso", Commercial: "Long Panatela", Length: 159, Ring: 33},
+	{Galera: "Cazador", Commercial: "Long Corona", Length: 162, Ring: 43},
+	{Galera: "Sublime", Commercial: "Double Robusto", Length: 164, Ring: 54},
+	{Galera: "Cervante", Commercial: "Lonsdale", Length: 165, Ring: 42},
+	{Galera: "Dalia", Commercial: "Lonsdale", Length: 170, Ring: 43},
+	{Galera: "Julieta No. 2", Commercial: "Churchill", Length: 178, Ring: 47},
+	{Galera: "Laguito No. 1", Commercial: "Lancero", Length: 192, Ring: 38},
+	{Galera: "Prominente", Commercial: "Double Corona", Length: 194, Ring: 49},
+	{Galera: "Gran Corona", Commercial: "Gran Corona", Length: 235, Ring: 47},
+	{Commercial: "Petit Corona", Length: 114, Ring: 42},
+	{Commercial: "Robusto", Length: 127, Ring: 50},
+	{Commercial: "Corona", Length: 140, Ring: 42},
+	{Commercial: "Toro", Length: 152, Ring: 50},
+	{Commercial: "Churchill", Length: 178, Ring: 48},
+	{Commercial: "Lancero", Length: 190, Ring: 40},
+	{Commercial: "Double Corona", Length: 194, Ring: 50},
+	{Commercial: "Presidente", Length: 203, Ring: 52},
+}
+
+// the differences of the size which reduce the vitola's score to exp(-1/2) ~ 0.6.
+const (
+	vitolaLengthScale = 5.
+	vitolaRingScale   = 1.5
+)
+
+// VitolaMatch defines the vitola classified by the cigar's size.
+type VitolaMatch struct {
+	Vitola
+	// Confidence the closeness of the size to the vitola from 0 to 1, it is discounted by the closeness of the size
+	// to the runner-up vitola of another commercial size.
+	Confidence float64
+}
+
+// ClassifyVitola returns the vitola closest to the cigar's length in mm and the ring gauge.
+// The figurados are not classified because their shape is not told by the size.
+// It returns false if the length, or the ring gauge is not known.
+func ClassifyVitola(length, ring float64) (VitolaMatch, bool) {
+	if length <= 0 || ring <= 0 {
+		return VitolaMatch{}, false
+	}
+	score := func(v Vitola) float64 {
+		dl := (length - v.Length) / vitolaLengthScale
+		dr := (ring - v.Ring) / vitolaRingScale
+		return math.Exp(-(dl*dl + dr*dr) / 2)
+	}
+	var (
+		best          Vitola
+		first, second float64
+	)
+	for _, v := range Vitolas {
+		if s := score(v); !v.Figurado && s > first {
+			best, first = v, s
+		}
+	}
+	// the vitolas of the same commercial size, e.g., Robusto and its vitola de galera, do not compete
+	for _, v := range Vitolas {
+		if !v.Figurado && !strings.EqualFold(v.Commercial, best.Commercial) {
+			second = max(second, score(v))
+		}
+	}
+	var confidence float64
+	if first > 0 {
+		confidence = math.Round(first*first/(first+second)*100) / 100
+	}
+	return VitolaMatch{Vitola: best, Confidence: confidence}, true
+}
+
+// Is reports whether the name is the vitola's vitola de galera, or the commercial name, ignoring the case.
+func (v Vitola) Is(name string) bool {
+	name = strings.TrimSpace(name)
+	return (v.Galera != "" && strings.EqualFold(v.Galera, name)) || strings.EqualFold(v.Commercial, name)
+}
+
+// IsVitola reports whether the name is a name of a vitola, i.e., the size of the format is known.
+func IsVitola(name string) bool {
+	return slices.ContainsFunc(Vitolas, func(v Vitola) bool { return v.Is(name) })
+}
+
+// IsFigurado reports whether the name is a name of a figurado vitola, e.g., Torpedo.
+func IsFigurado(name string) bool {
+	return slices.ContainsFunc(Vitolas, func(v Vitola) bool { return v.Figurado && v.Is(name) })
+}
+
+// LookupName returns the format's canonical name, the vitolas' names, singular or plural, are known formats as well.
+func (s Format) LookupName() (string, bool) {
+	if o, ok := s.Lookup(); ok {
+		return o, true
+	}
+	k := strings.ToLower(strings.TrimSpace(string(s)))
+	for _, v := range Vitolas {
+		for _, name := range []string{v.Galera, v.Commercial} {
+			if name != "" && (strings.ToLower(name) == k || strings.ToLower(name)+"s" == k) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// LookupSize returns the format's canonical name, see Format.LookupName, or the name of the vitola classified by
+// the cigar's length in mm and the ring gauge if the format is not known, see ClassifyVitola.
+// It returns false if the format is not known and the vitola's confidence is lower than minConfidence.
+func (s Format) LookupSize(length, ring, minConfidence float64) (string, bool) {
+	if o, ok := s.LookupName(); ok {
+		return o, true
+	}
+	if m, ok := ClassifyVitola(length, ring); ok && m.Confidence >= minConfidence {
+		return m.Name(), true
+	}
+	return "", false
+}
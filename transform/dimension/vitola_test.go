@@ -0,0 +1,126 @@
+package dimension
+
+import "testing"
+
+func TestClassifyVitola(t *testing.T) {
+	tests := []struct {
+		name           string
+		length, ring   float64
+		want           string
+		wantCommercial string
+		wantOK         bool
+		minConfidence  float64
+	}{
+		{name: "robusto", length: 124, ring: 50, want: "Robusto", wantCommercial: "Robusto", wantOK: true,
+			minConfidence: 0.7},
+		{name: "churchill", length: 178, ring: 47, want: "Julieta No. 2", wantCommercial: "Churchill", wantOK: true,
+			minConfidence: 0.9},
+		{name: "close to lancero", length: 190, ring: 39, want: "Lancero", wantCommercial: "Lancero",
+			wantOK: true, minConfidence: 0.7},
+		{name: "commercial robusto", length: 127, ring: 50, want: "Robusto", wantCommercial: "Robusto", wantOK: true,
+			minConfidence: 0.7},
+		{name: "toro", length: 152, ring: 50, want: "Toro", wantCommercial: "Toro", wantOK: true,
+			minConfidence: 0.9},
+		{name: "double corona", length: 190, ring: 50, want: "Double Corona", wantCommercial: "Double Corona",
+			wantOK: true, minConfidence: 0.6},
+		{name: "petit corona", length: 129, ring: 42, want: "Mareva", wantCommercial: "Petit Corona", wantOK: true,
+			minConfidence: 0.9},
+		{name: "no ring", length: 129},
+		{name: "no length", ring: 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ClassifyVitola(tt.length, tt.ring)
+			if ok != tt.wantOK {
+				t.Fatalf("ClassifyVitola() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got.Name() != tt.want || got.Commercial != tt.wantCommercial {
+				t.Errorf("ClassifyVitola() = %v, want %v, %v", got, tt.want, tt.wantCommercial)
+			}
+			if got.Confidence < tt.minConfidence || got.Confidence > 1 {
+				t.Errorf("ClassifyVitola() confidence = %v, want at least %v", got.Confidence, tt.minConfidence)
+			}
+		})
+	}
+
+	t.Run("ambiguous size", func(t *testing.T) {
+		// between Robusto, 127/50 and Edmundo, 135/52
+		got, _ := ClassifyVitola(131, 51)
+		if got.Confidence > 0.5 {
+			t.Errorf("ClassifyVitola() confidence = %v, want at most 0.5", got.Confidence)
+		}
+	})
+
+	t.Run("figurado is not classified by the size", func(t *testing.T) {
+		// the size of Campana, or Belicoso
+		got, _ := ClassifyVitola(140, 52)
+		if got.Figurado || got.Confidence >= 0.6 {
+			t.Errorf("ClassifyVitola() = %v, want the parejo of confidence lower than 0.6", got)
+		}
+	})
+}
+
+func TestFormat_LookupSize(t *testing.T) {
+	tests := []struct {
+		s            Format
+		length, ring float64
+		want         string
+		wantOK       bool
+	}{
+		{s: "Robustos", length: 140, ring: 52, want: "Robusto", wantOK: true},
+		{s: "Churchill", length: 170, ring: 43, want: "Churchill", wantOK: true},
+		{s: "Torpedos", want: "Torpedo", wantOK: true},
+		{s: "No. 2", length: 124, ring: 50, want: "Robusto", wantOK: true},
+		{s: "", length: 129, ring: 42, want: "Mareva", wantOK: true},
+		{s: "No. 2", length: 131, ring: 51},
+		{s: "No. 2"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.s), func(t *testing.T) {
+			got, ok := tt.s.LookupSize(tt.length, tt.ring, 0.6)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("LookupSize() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsVitola(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "Robusto", want: true},
+		{name: "julieta no. 2", want: true},
+		{name: "Churchill", want: true},
+		{name: "Belvedere"},
+		{name: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsVitola(tt.name); got != tt.want {
+				t.Errorf("IsVitola() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsFigurado(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "Torpedo", want: true},
+		{name: "pirámide", want: true},
+		{name: "Belicoso", want: true},
+		{name: "Robusto"},
+		{name: "Belvedere"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsFigurado(tt.name); got != tt.want {
+				t.Errorf("IsFigurado() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+// Package transform defines the pipeline to normalise the records' values between the extraction and the storage.
+package transform
+
+import (
+	"cigarsdb/storage"
+	"cigarsdb/transform/dimension"
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Normalizer normalises the record's values in place and returns the values it could not map.
+type Normalizer interface {
+	Normalize(r *storage.Record) []Unmapped
+}
+
+// NormalizerFunc defines the function which implements the Normalizer.
+type NormalizerFunc func(r *storage.Record) []Unmapped
+
+func (f NormalizerFunc) Normalize(r *storage.Record) []Unmapped {
+	return f(r)
+}
+
+// Unmapped defines the field's value which the normalizer could not map.
+type Unmapped struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+	// Conflict indicates the value which contradicts the record's other values, e.g., the length in mm and in inches.
+	// The conflicts are reported per record instead of the unmapped values, see Pipeline.Conflicts.
+	Conflict bool `json:"-"`
+}
+
+// Conflict defines the record's value which contradicts its other values.
+type Conflict struct {
+	URL   string `json:"url"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// Lookup defines the function which maps the value to its canonical form, it returns false if the value is not known.
+type Lookup func(v string) (string, bool)
+
+// Fields normalises the record's fields using the lookup.
+// The fields must be string, *string, or []string, the values mapped to the empty string are dropped.
+// The unknown values are kept as converted by fallback, or intact if fallback is nil.
+func Fields(lookup Lookup, fallback func(v string) string, fields ...string) Normalizer {
+	for _, field := range fields {
+		switch f, ok := reflect.TypeOf(storage.Record{}).FieldByName(field); {
+		case !ok:
+			panic(fmt.Sprintf("transform: field %s is not found", field))
+		case f.Type != reflect.TypeOf("") && f.Type != reflect.TypeOf(new(string)) &&
+			f.Type != reflect.TypeOf([]string{}):
+			panic(fmt.Sprintf("transform: field %s is not string, *string, or []string", field))
+		}
+	}
+
+	return NormalizerFunc(func(r *storage.Record) []Unmapped {
+		var o []Unmapped
+		convert := func(field, v string) (string, bool) {
+			if strings.TrimSpace(v) == "" {
+				return "", false
+			}
+			vv, ok := lookup(v)
+			if !ok {
+				o = append(o, Unmapped{Field: field, Value: v})
+				vv = v
+				if fallback != nil {
+					vv = fallback(v)
+				}
+			}
+			return vv, vv != ""
+		}
+
+		rv := reflect.ValueOf(r).Elem()
+		for _, field := range fields {
+			switch v := rv.FieldByName(field).Addr().Interface().(type) {
+			case *string:
+				*v, _ = convert(field, *v)
+			case **string:
+				if *v != nil {
+					switch vv, ok := convert(field, **v); ok {
+					case true:
+						*v = &vv
+					case false:
+						*v = nil
+					}
+				}
+			case *[]string:
+				if len(*v) > 0 {
+					var vals []string
+					for _, el := range *v {
+						if vv, ok := convert(field, el); ok && !slices.Contains(vals, vv) {
+							vals = append(vals, vv)
+						}
+					}
+					*v = vals
+				}
+			}
+		}
+		return o
+	})
+}
+
+// Origins normalises the countries of origin, see dimension.Country.
+func Origins() Normalizer {
+	return Fields(lookup[dimension.Country], func(v string) string { return dimension.Country(v).Convert() },
+		"ManufactureOrigin", "WrapperOrigin", "FillerOrigin", "BinderOrigin")
+}
+
+// Format normalises the cigar's format, see dimension.Format.
+func Format() Normalizer {
+	return Fields(lookup[dimension.Format], func(v string) string { return dimension.Format(v).Convert() },
+		"Format")
+}
+
+// TobaccoVarieties normalises the tobacco varieties of the wrapper, the filler and the binder,
+// see dimension.TobaccoType.
+func TobaccoVarieties() Normalizer {
+	return Fields(lookup[dimension.TobaccoType], nil,
+		"WrapperTobaccoVariety", "FillerTobaccoVariety", "BinderTobaccoVariety")
+}
+
+// Strength normalises the cigar's and the flavour's strength to the canonical labels of dimension.StrengthLevel.
+func Strength() Normalizer {
+	return Fields(lookup[dimension.Strength], func(v string) string { return dimension.Strength(v).Convert() },
+		"Strength", "FlavourStrength")
+}
+
+// Color normalises the wrapper's colour to the canonical labels of dimension.ColorShade.
+// The colour is inferred from the wrapper's tobacco varieties if the record has none,
+// see dimension.ColorFromTobaccoVariety.
+func Color() Normalizer {
+	n := Fields(lookup[dimension.Color], func(v string) string { return dimension.Color(v).Convert() }, "Color")
+	return NormalizerFunc(func(r *storage.Record) []Unmapped {
+		o := n.Normalize(r)
+		if r.Color == nil {
+			if c, ok := dimension.ColorFromTobaccoVariety(r.WrapperTobaccoVariety...); ok {
+				v := string(c)
+				r.Color = &v
+			}
+		}
+		return o
+	})
+}
+
+// Shape derives the cigar's missing diameter, ring gauge, length in mm, or length in inches from the present ones,
+// see dimension.Shape.Resolve. The values contradicting each other beyond the relative tolerance are reported as
+// the conflicts, e.g., Length "140 mm contradicts LengthInch 6 inch".
+func Shape(tolerance float64) Normalizer {
+	return NormalizerFunc(func(r *storage.Record) []Unmapped {
+		s, conflicts := dimension.Shape{
+			Diameter: r.Diameter, Ring: r.Ring, Length: r.Length, LengthInch: r.LengthInch,
+		}.Resolve(tolerance)
+		r.Diameter, r.Ring, r.Length, r.LengthInch = s.Diameter, s.Ring, s.Length, s.LengthInch
+		var o []Unmapped
+		for _, c := range conflicts {
+			o = append(o, Unmapped{Field: c.Field, Value: c.String(), Conflict: true})
+		}
+		return o
+	})
+}
+
+// Vitola fills the cigar's format which is empty, or not known, e.g., "No. 2", by the vitola classified by the cigar's
+// length and ring gauge if the vitola's confidence is at least minConfidence, see dimension.ClassifyVitola.
+// The replaced format is kept in the record's Details by the key "format". The known format of the vitola which
+// contradicts the confidently classified one is kept and reported as unmapped, the figurados are not cross-checked
+// because their shape is not told by the size.
+func Vitola(minConfidence float64) Normalizer {
+	return NormalizerFunc(func(r *storage.Record) []Unmapped {
+		m, ok := dimension.ClassifyVitola(r.Length, r.Ring)
+		ok = ok && m.Confidence >= minConfidence
+		switch v, known := dimension.Format(r.Format).LookupName(); {
+		case known:
+			r.Format = v
+			if ok && dimension.IsVitola(v) && !dimension.IsFigurado(v) && !m.Is(v) {
+				return []Unmapped{{Field: "Format",
+					Value: fmt.Sprintf("%s contradicts %s of %v mm, %v ring", v, m.Name(), r.Length, r.Ring)}}
+			}
+		case ok:
+			if r.Format != "" {
+				// the details are shared with the caller's record, see Pipeline.Writer
+				r.Details = maps.Clone(r.Details)
+				if r.Details == nil {
+					r.Details = make(map[string]string, 1)
+				}
+				r.Details["format"] = r.Format
+			}
+			r.Format = m.Name()
+		}
+		return nil
+	})
+}
+
+func lookup[D interface {
+	~string
+	dimension.Lookup
+}](v string) (string, bool) {
+	return D(v).Lookup()
+}
+
+// Defaults returns the normalizers applied by default, the format is filled by the vitola after it is normalised
+// and the shape is resolved.
+func Defaults() []Normalizer {
+	return []Normalizer{Origins(), Format(), TobaccoVarieties(), Strength(), Color(), Shape(0.05), Vitola(0.6)}
+}
+
+// UnmappedCount defines the unmapped value with the number of its occurrences.
+type UnmappedCount struct {
+	Unmapped
+	Count int `json:"count"`
+}
+
+// Pipeline applies the chain of normalizers to the records and collects the values they could not map,
+// and the conflicts of the records' values.
+type Pipeline struct {
+	Normalizers []Normalizer
+
+	mu        *sync.Mutex
+	unmapped  map[Unmapped]int
+	conflicts []Conflict
+}
+
+// NewPipeline initialises the Pipeline which applies the normalizers in the given order.
+func NewPipeline(normalizers ...Normalizer) *Pipeline {
+	return &Pipeline{Normalizers: normalizers, mu: new(sync.Mutex), unmapped: make(map[Unmapped]int)}
+}
+
+// Apply normalises the record.
+func (p *Pipeline) Apply(r *storage.Record) {
+	var unmapped []Unmapped
+	for _, n := range p.Normalizers {
+		unmapped = append(unmapped, n.Normalize(r)...)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, u := range unmapped {
+		switch u.Conflict {
+		case true:
+			p.conflicts = append(p.conflicts, Conflict{URL: r.URL, Field: u.Field, Value: u.Value})
+		case false:
+			p.unmapped[u]++
+		}
+	}
+}
+
+// Conflicts returns the conflicts of the records' values found so far in the order of the records.
+func (p *Pipeline) Conflicts() []Conflict {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Conflict(nil), p.conflicts...)
+}
+
+// Unmapped returns the values which could not be mapped so far sorted by the field and the value.
+func (p *Pipeline) Unmapped() []UnmappedCount {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var o = make([]UnmappedCount, 0, len(p.unmapped))
+	for _, u := range slices.SortedFunc(maps.Keys(p.unmapped), func(a, b Unmapped) int {
+		return cmp.Or(cmp.Compare(a.Field, b.Field), cmp.Compare(a.Value, b.Value))
+	}) {
+		o = append(o, UnmappedCount{Unmapped: u, Count: p.unmapped[u]})
+	}
+	return o
+}
+
+// Reader wraps the reader to normalise the records it reads.
+func (p *Pipeline) Reader(r storage.Reader) storage.Reader {
+	return pipelineReader{Reader: r, pipeline: p}
+}
+
+// Writer wraps the writer to normalise the records before they are written.
+// The written records are copied, hence the caller's records stay intact.
+func (p *Pipeline) Writer(w storage.Writer) storage.Writer {
+	return pipelineWriter{Writer: w, pipeline: p}
+}
+
+type pipelineReader struct {
+	storage.Reader
+	pipeline *Pipeline
+}
+
+func (r pipelineReader) Read(ctx context.Context, id string) (storage.Record, error) {
+	o, err := r.Reader.Read(ctx, id)
+	if err == nil {
+		r.pipeline.Apply(&o)
+	}
+	return o, err
+}
+
+func (r pipelineReader) ReadBulk(ctx context.Context, limit, page uint) ([]storage.Record, uint, error) {
+	o, nextPage, err := r.Reader.ReadBulk(ctx, limit, page)
+	for i := range o {
+		r.pipeline.Apply(&o[i])
+	}
+	return o, nextPage, err
+}
+
+type pipelineWriter struct {
+	storage.Writer
+	pipeline *Pipeline
+}
+
+func (w pipelineWriter) Write(ctx context.Context, r []storage.Record) ([]string, error) {
+	r = slices.Clone(r)
+	for i := range r {
+		w.pipeline.Apply(&r[i])
+	}
+	return w.Writer.Write(ctx, r)
+}
@@ -0,0 +1,228 @@
+package transform
+
+import (
+	"cigarsdb/storage"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStorage struct {
+	records []storage.Record
+	written []storage.Record
+}
+
+func (m *mockStorage) Read(_ context.Context, _ string) (storage.Record, error) {
+	return m.records[0], nil
+}
+
+func (m *mockStorage) ReadBulk(_ context.Context, _, _ uint) ([]storage.Record, uint, error) {
+	return m.records, 0, nil
+}
+
+func (m *mockStorage) Write(_ context.Context, r []storage.Record) ([]string, error) {
+	m.written = append(m.written, r...)
+	return make([]string, len(r)), nil
+}
+
+func newRecord() storage.Record {
+	wrapper := "sungrown"
+	strength := "Mild bis Medium"
+	return storage.Record{
+		Name:                  "foo",
+		ManufactureOrigin:     "Dominikanische Republik",
+		WrapperOrigin:         []string{"Kuba", "Cuba", "Atlantis", "unbekannt"},
+		Format:                "Pyramides",
+		WrapperTobaccoVariety: []string{"sungrown", "Habano 2000", "Corojo Maduro"},
+		FillerTobaccoVariety:  []string{},
+		AdditionalNotes:       &wrapper,
+		Strength:              &strength,
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	wantStrength := "Mild-Medium"
+	wantColor := "Maduro"
+	want := storage.Record{
+		Name:                  "foo",
+		ManufactureOrigin:     "Dominican Republic",
+		WrapperOrigin:         []string{"Cuba", "Atlantis"},
+		Format:                "Pirámide",
+		WrapperTobaccoVariety: []string{"Sun Grown", "Habano 2000", "Corojo Maduro"},
+		FillerTobaccoVariety:  []string{},
+		AdditionalNotes:       newRecord().AdditionalNotes,
+		Strength:              &wantStrength,
+		Color:                 &wantColor,
+	}
+	wantUnmapped := []UnmappedCount{
+		{Unmapped: Unmapped{Field: "WrapperOrigin", Value: "Atlantis"}, Count: 2},
+		{Unmapped: Unmapped{Field: "WrapperTobaccoVariety", Value: "Habano 2000"}, Count: 2},
+	}
+
+	t.Run("reader", func(t *testing.T) {
+		p := NewPipeline(Defaults()...)
+		got, _, err := p.Reader(&mockStorage{records: []storage.Record{newRecord(), newRecord()}}).ReadBulk(
+			context.TODO(), 0, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.Record{want, want}, got)
+		assert.Equal(t, wantUnmapped, p.Unmapped())
+	})
+
+	t.Run("writer keeps the caller's records intact", func(t *testing.T) {
+		p := NewPipeline(Defaults()...)
+		w := &mockStorage{}
+		in := []storage.Record{newRecord(), newRecord()}
+		_, err := p.Writer(w).Write(context.TODO(), in)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.Record{want, want}, w.written)
+		assert.Equal(t, []storage.Record{newRecord(), newRecord()}, in)
+		assert.Equal(t, wantUnmapped, p.Unmapped())
+	})
+
+	t.Run("spelling variants are identical", func(t *testing.T) {
+		var records []storage.Record
+		for _, v := range []string{"sungrown", "sun-grown", "sun grown", "Sun Grown", "SUN GROWN"} {
+			records = append(records, storage.Record{Name: "foo", WrapperTobaccoVariety: []string{v}})
+		}
+		p := NewPipeline(Defaults()...)
+		got, _, err := p.Reader(&mockStorage{records: records}).ReadBulk(context.TODO(), 0, 0)
+		assert.NoError(t, err)
+		for _, r := range got {
+			assert.Equal(t, []string{"Sun Grown"}, r.WrapperTobaccoVariety)
+		}
+		assert.Empty(t, p.Unmapped())
+	})
+}
+
+func TestColor(t *testing.T) {
+	t.Run("explicit colour", func(t *testing.T) {
+		c := "natural"
+		r := storage.Record{Color: &c, WrapperTobaccoVariety: []string{"Corojo Maduro"}}
+		assert.Empty(t, Color().Normalize(&r))
+		assert.Equal(t, "Colorado Claro", *r.Color)
+	})
+
+	t.Run("unknown colour", func(t *testing.T) {
+		c := "Shade"
+		r := storage.Record{Color: &c}
+		assert.Equal(t, []Unmapped{{Field: "Color", Value: "Shade"}}, Color().Normalize(&r))
+		assert.Equal(t, "Shade", *r.Color)
+	})
+
+	t.Run("no colour", func(t *testing.T) {
+		r := storage.Record{WrapperTobaccoVariety: []string{"Connecticut Shade"}}
+		assert.Empty(t, Color().Normalize(&r))
+		assert.Nil(t, r.Color)
+	})
+}
+
+func TestShape(t *testing.T) {
+	t.Run("inches and ring gauge", func(t *testing.T) {
+		r := storage.Record{LengthInch: 4.875, Ring: 50}
+		assert.Empty(t, Shape(0.05).Normalize(&r))
+		assert.Equal(t, storage.Record{Diameter: 19.8, Ring: 50, Length: 123.8, LengthInch: 4.88}, r)
+	})
+
+	t.Run("contradiction", func(t *testing.T) {
+		r := storage.Record{Diameter: 19.8, Ring: 50, Length: 140, LengthInch: 6}
+		assert.Equal(t, []Unmapped{{Field: "Length", Value: "140 mm contradicts LengthInch 6 inch", Conflict: true}},
+			Shape(0.05).Normalize(&r))
+		assert.Equal(t, storage.Record{Diameter: 19.8, Ring: 50, Length: 140, LengthInch: 6}, r)
+	})
+
+	t.Run("conflicts are reported per record", func(t *testing.T) {
+		p := NewPipeline(Defaults()...)
+		_, _, err := p.Reader(&mockStorage{records: []storage.Record{
+			{Name: "foo", URL: "https://foo.com/foo", Diameter: 19.8, Ring: 50, Length: 140, LengthInch: 6},
+			{Name: "bar", URL: "https://foo.com/bar", Diameter: 19.8, Ring: 50, Length: 140, LengthInch: 6},
+		}}).ReadBulk(context.TODO(), 0, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []Conflict{
+			{URL: "https://foo.com/foo", Field: "Length", Value: "140 mm contradicts LengthInch 6 inch"},
+			{URL: "https://foo.com/bar", Field: "Length", Value: "140 mm contradicts LengthInch 6 inch"},
+		}, p.Conflicts())
+		assert.Empty(t, p.Unmapped())
+	})
+
+	t.Run("vitola by the resolved shape", func(t *testing.T) {
+		r := storage.Record{Format: "No. 2", LengthInch: 4.875, Ring: 50}
+		NewPipeline(Defaults()...).Apply(&r)
+		assert.Equal(t, "Robusto", r.Format)
+	})
+}
+
+func TestVitola(t *testing.T) {
+	t.Run("brand-specific format", func(t *testing.T) {
+		r := storage.Record{Format: "No. 2", Length: 124, Ring: 50}
+		assert.Empty(t, Vitola(0.6).Normalize(&r))
+		assert.Equal(t, "Robusto", r.Format)
+		assert.Equal(t, map[string]string{"format": "No. 2"}, r.Details)
+	})
+
+	t.Run("known format", func(t *testing.T) {
+		r := storage.Record{Format: "churchills", Length: 178, Ring: 47}
+		assert.Empty(t, Vitola(0.6).Normalize(&r))
+		assert.Equal(t, "Churchill", r.Format)
+		assert.Nil(t, r.Details)
+	})
+
+	t.Run("known format contradicts the size", func(t *testing.T) {
+		r := storage.Record{Format: "Churchill", Length: 124, Ring: 50}
+		assert.Equal(t, []Unmapped{{Field: "Format", Value: "Churchill contradicts Robusto of 124 mm, 50 ring"}},
+			Vitola(0.6).Normalize(&r))
+		assert.Equal(t, "Churchill", r.Format)
+	})
+
+	t.Run("figurado is not cross-checked by the size", func(t *testing.T) {
+		r := storage.Record{Format: "Torpedo", Length: 152, Ring: 50}
+		assert.Empty(t, Vitola(0.6).Normalize(&r))
+		assert.Equal(t, "Torpedo", r.Format)
+	})
+
+	t.Run("replaced format does not change the caller's details", func(t *testing.T) {
+		p := NewPipeline(Vitola(0.6))
+		w := &mockStorage{}
+		in := []storage.Record{{Name: "foo", Format: "No. 2", Length: 127, Ring: 50,
+			Details: map[string]string{"description": "bar"}}}
+		_, err := p.Writer(w).Write(context.TODO(), in)
+		assert.NoError(t, err)
+		assert.Equal(t, "Robusto", w.written[0].Format)
+		assert.Equal(t, map[string]string{"description": "bar", "format": "No. 2"}, w.written[0].Details)
+		assert.Equal(t, map[string]string{"description": "bar"}, in[0].Details)
+	})
+
+	t.Run("known format of unknown size", func(t *testing.T) {
+		r := storage.Record{Format: "Belvederes", Length: 124, Ring: 50}
+		assert.Empty(t, Vitola(0.6).Normalize(&r))
+		assert.Equal(t, "Belvedere", r.Format)
+	})
+
+	t.Run("ambiguous size", func(t *testing.T) {
+		r := storage.Record{Length: 131, Ring: 51}
+		assert.Empty(t, Vitola(0.6).Normalize(&r))
+		assert.Empty(t, r.Format)
+	})
+}
+
+func TestFields(t *testing.T) {
+	t.Run("unknown field", func(t *testing.T) {
+		assert.Panics(t, func() { Fields(nil, nil, "Foo") })
+	})
+
+	t.Run("unsupported field type", func(t *testing.T) {
+		assert.Panics(t, func() { Fields(nil, nil, "Price") })
+	})
+
+	t.Run("pointer to string", func(t *testing.T) {
+		upper := func(v string) (string, bool) { return map[string]string{"a": "A", "b": ""}[v], v != "c" }
+		a, b, c := "a", "b", "c"
+		n := Fields(upper, nil, "Color", "Strength", "Maker", "FlavourStrength")
+		r := storage.Record{Color: &a, Strength: &b, Maker: &c}
+		assert.Equal(t, []Unmapped{{Field: "Maker", Value: "c"}}, n.Normalize(&r))
+		assert.Equal(t, "A", *r.Color)
+		assert.Nil(t, r.Strength)
+		assert.Equal(t, "c", *r.Maker)
+		assert.Nil(t, r.FlavourStrength)
+	})
+}